@@ -0,0 +1,150 @@
+package sorted_numeric_streams
+
+import (
+	"context"
+	"sync"
+)
+
+// adaptiveQueue is a FIFO queue with a buffer limit adjusted to the observed producer and consumer rates.
+// Every window of 2*limit pushes and pops it compares how often each side had to wait:
+//   - the producer waited on a full buffer more often than the consumer on an empty one: the consumer is slower,
+//     the limit doubles (up to max) so the producer runs ahead and a consumer speeding up finds items ready
+//   - the consumer waited more often: the producer is slower and a big buffer only holds memory,
+//     the limit halves (down to min) and the storage is reallocated once it is much larger than needed
+type adaptiveQueue[T any] struct {
+	mu                    sync.Mutex
+	notEmpty, notFull     sync.Cond
+	items                 []T
+	limit, min, max       int
+	closed                bool
+	ops                   int // pushes and pops in the current window
+	fullWaits, emptyWaits int
+}
+
+func newAdaptiveQueue[T any](min, max int) *adaptiveQueue[T] {
+	q := &adaptiveQueue[T]{items: make([]T, 0, min), limit: min, min: min, max: max}
+	q.notEmpty.L, q.notFull.L = &q.mu, &q.mu
+	return q
+}
+
+func (q *adaptiveQueue[T]) push(item T) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) >= q.limit && !q.closed {
+		q.fullWaits++
+		for len(q.items) >= q.limit && !q.closed {
+			q.notFull.Wait()
+		}
+	}
+	q.put(item)
+}
+
+// pushContext is push which gives up on a full buffer once ctx is done, it returns false if the item is not queued.
+// The producer waiting for buffer space is woken up by wake, it must be called once ctx is done (see context.AfterFunc)
+func (q *adaptiveQueue[T]) pushContext(ctx context.Context, item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) >= q.limit && !q.closed {
+		q.fullWaits++
+		for len(q.items) >= q.limit && !q.closed && ctx.Err() == nil {
+			q.notFull.Wait()
+		}
+	}
+	if len(q.items) >= q.limit && !q.closed {
+		return false
+	}
+	q.put(item)
+	return true
+}
+
+// wake makes pushContext check its context
+func (q *adaptiveQueue[T]) wake() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.notFull.Broadcast()
+}
+
+// takeAll removes the queued items
+func (q *adaptiveQueue[T]) takeAll() []T {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	items := q.items
+	q.items = nil
+	q.notFull.Broadcast()
+	return items
+}
+
+func (q *adaptiveQueue[T]) tryPush(item T) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) >= q.limit && !q.closed {
+		q.fullWaits++
+		return false
+	}
+	q.put(item)
+	return true
+}
+
+func (q *adaptiveQueue[T]) put(item T) {
+	if q.closed {
+		panic("push to a closed stream")
+	}
+	q.items = append(q.items, item)
+	q.notEmpty.Signal()
+	q.adapt()
+}
+
+func (q *adaptiveQueue[T]) pop() (item T, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.items) == 0 && !q.closed {
+		q.emptyWaits++
+		for len(q.items) == 0 && !q.closed {
+			q.notEmpty.Wait()
+		}
+	}
+	if len(q.items) == 0 {
+		return item, false
+	}
+	var empty T
+	item, q.items[0] = q.items[0], empty // so the storage does not keep the item alive
+	q.items = q.items[1:]
+	q.notFull.Signal()
+	q.adapt()
+	return item, true
+}
+
+func (q *adaptiveQueue[T]) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+// adapt resizes the limit at the end of a window, see adaptiveQueue
+func (q *adaptiveQueue[T]) adapt() {
+	if q.ops++; q.ops < 2*q.limit {
+		return
+	}
+	switch {
+	case q.fullWaits > q.emptyWaits && q.limit < q.max:
+		q.limit = min(2*q.limit, q.max)
+		q.notFull.Broadcast()
+	case q.emptyWaits > q.fullWaits && q.limit > q.min:
+		q.limit = max(q.limit/2, q.min)
+	}
+	if cap(q.items) > 4*q.limit && len(q.items) <= q.limit {
+		items := make([]T, len(q.items), q.limit)
+		copy(items, q.items)
+		q.items = items
+	}
+	q.ops, q.fullWaits, q.emptyWaits = 0, 0, 0
+}
+
+// currentLimit returns the buffer limit, for tests
+func (q *adaptiveQueue[T]) currentLimit() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit
+}
@@ -0,0 +1,101 @@
+package sorted_numeric_streams
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdaptiveQueue drives both sides step by step: each side is made to wait on the other a known number of times
+func TestAdaptiveQueue(t *testing.T) {
+	q := newAdaptiveQueue[int](2, 64)
+	pushed, read := 0, 0
+	pop := func() {
+		item, ok := q.pop()
+		require.True(t, ok)
+		require.Equal(t, read, item)
+		read++
+	}
+
+	// a slow consumer: the producer finds the buffer full before every pop
+	for i := 0; i < 1000 && q.currentLimit() < 64; i++ {
+		for q.tryPush(pushed) {
+			pushed++
+		}
+		pop()
+	}
+	require.Equal(t, 64, q.currentLimit())
+
+	// a slow producer: the consumer drains the buffer and waits for every push
+	for read < pushed {
+		pop()
+	}
+	emptyWaits := func() int {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		return q.emptyWaits
+	}
+	for i := 0; i < 1000 && q.currentLimit() > 2; i++ {
+		waits := emptyWaits()
+		popped := make(chan int)
+		go func() {
+			item, _ := q.pop()
+			popped <- item
+		}()
+		for emptyWaits() == waits { // the pop found the buffer empty and waits
+			runtime.Gosched()
+		}
+		q.push(pushed)
+		require.Equal(t, pushed, <-popped)
+		pushed, read = pushed+1, read+1
+	}
+	require.Equal(t, 2, q.currentLimit())
+
+	q.close()
+	_, ok := q.pop()
+	require.False(t, ok)
+}
+
+func TestAdaptiveBufferBackend(t *testing.T) {
+	a, b := NewRangeStream(0, 1000, 2), NewRangeStream(0, 1000, 3)
+	result := Union[int](a, b, true, WithAdaptiveBuffer(1, 16))
+	require.EqualValues(t, ToSlice(Union[int](NewRangeStream(0, 1000, 2), NewRangeStream(0, 1000, 3), true)), ToSlice(result))
+
+	s := &ChannelStream[int]{queue: newAdaptiveQueue[int](1, 1)}
+	require.True(t, s.TryPush(1))
+	require.False(t, s.TryPush(2)) // full
+	s.Close()
+	require.NotPanics(t, s.Close)
+	require.EqualValues(t, []int{1}, ToSlice[int](s))
+}
+
+// BenchmarkAdaptiveBuffer reads a union slowly and then fast: a small fixed buffer stalls the merge in the slow phase,
+// a big one holds its memory for the whole run, the adaptive buffer grows only while it helps
+func BenchmarkAdaptiveBuffer(b *testing.B) {
+	const items, slowItems = 20_000, 200
+	backends := []struct {
+		name string
+		opt  Option
+	}{
+		{"fixed 4", WithChannelBackend(4)},
+		{"fixed 1024", WithChannelBackend(1024)},
+		{"adaptive 4-1024", WithAdaptiveBuffer(4, 1024)},
+	}
+	for _, backend := range backends {
+		b.Run(backend.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				result := Union[int](NewRangeStream(0, items, 1), NewRangeStream(0, items, 2), true, backend.opt)
+				for n := 0; ; n++ {
+					if _, ok := result.Next(); !ok {
+						break
+					}
+					if n < slowItems {
+						time.Sleep(time.Microsecond)
+					}
+				}
+			}
+		})
+	}
+}
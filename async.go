@@ -0,0 +1,58 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// UnionAsync is Union for Errorable (e.g. I/O-backed) operands: if an operand fails mid-merge,
+// the merge stops and the error is delivered to the returned channel instead of producing a wrong result.
+// The channel delivers at most one error and is closed when the merge is finished.
+func UnionAsync[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) (SortedNumbersStream[T], <-chan error) {
+	errs := make(chan error, 1)
+	return Union(stream1, stream2, asc, append(opts, withErrors(errs))...), errs
+}
+
+// IntersectAsync is Intersect reporting operand failures to the returned channel (see UnionAsync)
+func IntersectAsync[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) (SortedNumbersStream[T], <-chan error) {
+	errs := make(chan error, 1)
+	return Intersect(stream1, stream2, asc, append(opts, withErrors(errs))...), errs
+}
+
+// DiffAsync is Diff reporting operand failures to the returned channel (see UnionAsync)
+func DiffAsync[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) (SortedNumbersStream[T], <-chan error) {
+	errs := make(chan error, 1)
+	return Diff(stream1, stream2, asc, append(opts, withErrors(errs))...), errs
+}
+
+func withErrors(errs chan error) Option {
+	return func(cfg *config) { cfg.errs = errs }
+}
+
+// operandFailure detects Errorable operands that stopped because of an error rather than being drained
+type operandFailure struct {
+	err error
+}
+
+// stopOn stops the merge on a failure, otherwise the operation would treat the failed operand as drained
+func (f *operandFailure) stopOn(stop shouldStop) shouldStop {
+	return func(aClosed, bClosed bool) bool { return f.err != nil || stop(aClosed, bClosed) }
+}
+
+// failureWatcher records the error of the Errorable operand once it stops
+type failureWatcher[T any] struct {
+	SortedNumbersStream[T]
+	errorable Errorable
+	failure   *operandFailure
+}
+
+func (s *failureWatcher[T]) Next() (item T, ok bool) {
+	if item, ok = s.SortedNumbersStream.Next(); !ok && s.failure.err == nil {
+		s.failure.err = s.errorable.Err()
+	}
+	return
+}
+
+func watchFailure[T any](f *operandFailure, stream SortedNumbersStream[T]) SortedNumbersStream[T] {
+	if e, ok := stream.(Errorable); ok {
+		return &failureWatcher[T]{stream, e, f}
+	}
+	return stream
+}
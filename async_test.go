@@ -0,0 +1,157 @@
+package sorted_numeric_streams
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// failingStream emits items and then fails instead of being drained
+type failingStream struct {
+	items []int
+	err   error
+}
+
+func (s *failingStream) Next() (item int, ok bool) {
+	if len(s.items) == 0 {
+		return 0, false
+	}
+	item, s.items = s.items[0], s.items[1:]
+	return item, true
+}
+
+func (s *failingStream) Err() error {
+	if len(s.items) == 0 {
+		return s.err
+	}
+	return nil
+}
+
+func TestAsyncReportsFailure(t *testing.T) {
+	failure := errors.New("disk failure")
+	a := &failingStream{[]int{1, 2}, failure}
+	b := NewSliceStream([]int{1, 3, 4, 5})
+
+	result, errs := UnionAsync[int](a, b, true)
+	items := ToSlice(result)
+	require.EqualValues(t, []int{1, 2}, items) // the rest of b is not emitted as if a was drained
+	require.ErrorIs(t, <-errs, failure)
+	_, open := <-errs
+	require.False(t, open)
+}
+
+func TestAsyncReportsFailureWithOptions(t *testing.T) {
+	failure := errors.New("disk failure")
+	options := map[string]Option{
+		"progress": WithProgress(1, func(readA, readB int) {}),
+		"context":  WithContext(context.Background()),
+	}
+	for name, opt := range options {
+		t.Run(name, func(t *testing.T) {
+			a := &failingStream{[]int{1, 2}, failure}
+			result, errs := UnionAsync[int](a, NewSliceStream([]int{1, 3, 4, 5}), true, opt)
+			require.EqualValues(t, []int{1, 2}, ToSlice(result))
+			require.ErrorIs(t, <-errs, failure)
+		})
+	}
+}
+
+// cancellingStream cancels the operation once it is drained, right after failing
+type cancellingStream struct {
+	*failingStream
+	cancel context.CancelFunc
+}
+
+func (s *cancellingStream) Next() (item int, ok bool) {
+	if item, ok = s.failingStream.Next(); !ok {
+		s.cancel()
+	}
+	return
+}
+
+func TestAsyncReportsOneError(t *testing.T) {
+	failure := errors.New("disk failure")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a := &cancellingStream{&failingStream{[]int{1, 2}, failure}, cancel}
+	reads := make(chan int, 100)
+	result, errs := UnionAsync[int](a, NewSliceStream([]int{1, 3, 4, 5}), true, WithContext(ctx), WithProgress(100, func(readA, readB int) {
+		reads <- readA
+	}))
+	ToSlice(result)
+
+	var reported []error
+	for err := range errs { // closed once the operation is finished
+		reported = append(reported, err)
+	}
+	require.Len(t, reported, 1)
+	require.ErrorIs(t, reported[0], failure)
+	select {
+	case readA := <-reads: // the final report
+		require.Equal(t, 2, readA)
+	case <-time.After(time.Second):
+		t.Fatal("the final progress is not reported")
+	}
+}
+
+func TestAsyncWithoutFailure(t *testing.T) {
+	a := NewLinesStream(strings.NewReader("a\nb\n"))
+	b := NewSliceStream([]string{})
+
+	result, errs := DiffAsync[string](a, b, true)
+	require.EqualValues(t, []string{"a", "b"}, ToSlice(result))
+	err, open := <-errs
+	require.NoError(t, err)
+	require.False(t, open)
+
+	result, errs = IntersectAsync[string](NewSliceStream([]string{"a"}), NewSliceStream([]string{"a"}), true)
+	require.EqualValues(t, []string{"a"}, ToSlice(result))
+	require.NoError(t, <-errs)
+}
+
+// panickingStream panics once its items are read
+type panickingStream struct {
+	items []int
+}
+
+func (s *panickingStream) Next() (item int, ok bool) {
+	if len(s.items) == 0 {
+		panic("corrupted index")
+	}
+	item, s.items = s.items[0], s.items[1:]
+	return item, true
+}
+
+func TestOperationPanicClosesResult(t *testing.T) {
+	result := Union[int](&panickingStream{[]int{1, 2}}, NewSliceStream([]int{5}), true)
+	require.EqualValues(t, []int{1, 2}, ToSlice(result)) // the reader is not blocked forever
+	require.ErrorIs(t, result.(Errorable).Err(), ErrPanic)
+	require.ErrorContains(t, result.(Errorable).Err(), "corrupted index")
+
+	result, errs := IntersectAsync[int](&panickingStream{[]int{1}}, NewSliceStream([]int{1, 2}), true)
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.ErrorIs(t, <-errs, ErrPanic)
+
+	pipeline := UnionPipeline[int](&panickingStream{}, NewSliceStream([]int{1}), true)
+	pipeline.Start()
+	require.EqualValues(t, []int{}, ToSlice[int](pipeline))
+	require.ErrorIs(t, pipeline.Err(), ErrPanic)
+}
+
+func TestPanickingCallback(t *testing.T) {
+	a := newRecordStream(record{1, "a1"}, record{2, "a2"})
+	b := newRecordStream(record{2, "b2"})
+	resolve := func(a, b record) record { panic("no resolution for " + a.payload) }
+
+	result := UnionFunc[record](a, b, true, compareRecords, resolve)
+	require.EqualValues(t, []record{{1, "a1"}}, ToSlice(result))
+	require.ErrorIs(t, result.(Errorable).Err(), ErrPanic)
+
+	ok := Union[int](NewSliceStream([]int{1}), NewSliceStream([]int{2}), true)
+	ToSlice(ok)
+	require.NoError(t, ok.(Errorable).Err())
+}
@@ -0,0 +1,129 @@
+package sorted_numeric_streams
+
+import "math/bits"
+
+// BitmapStream is the ascending stream of non-negative ints stored as a bitmap: bit i of the words is set if i is an item.
+// It is compact for dense sets, and IntersectBitmap, UnionBitmap and DiffBitmap combine bitmaps word by word
+type BitmapStream struct {
+	words []uint64
+	pos   int // the next bit to check
+}
+
+// NewBitmapStream returns the bitmap of the items, which must not be negative (their order does not matter)
+func NewBitmapStream(items []int) *BitmapStream {
+	var words []uint64
+	for _, item := range items {
+		if item < 0 {
+			panic("bitmap items must not be negative")
+		}
+		for item>>6 >= len(words) {
+			words = append(words, 0)
+		}
+		words[item>>6] |= 1 << (item & 63)
+	}
+	return &BitmapStream{words: words}
+}
+
+// FromBitmap returns the stream of the bits set in words, the slice is used without copying
+func FromBitmap(words []uint64) *BitmapStream {
+	return &BitmapStream{words: words}
+}
+
+func (s *BitmapStream) Next() (item int, ok bool) {
+	for s.pos < len(s.words)*64 {
+		word := s.words[s.pos>>6] >> (s.pos & 63)
+		if word == 0 {
+			s.pos = (s.pos>>6 + 1) << 6
+			continue
+		}
+		item = s.pos + bits.TrailingZeros64(word)
+		s.pos = item + 1
+		return item, true
+	}
+	return 0, false
+}
+
+// Len returns the number of remaining items, counting bits of the remaining words
+func (s *BitmapStream) Len() (int, bool) {
+	n := 0
+	for _, word := range s.remaining() {
+		n += bits.OnesCount64(word)
+	}
+	return n, true
+}
+
+func (s *BitmapStream) Asc() bool { return true }
+
+// Seek skips items below target, the bitmap is ascending so it panics if asc is false
+func (s *BitmapStream) Seek(target int, asc bool) {
+	if !asc {
+		panic("bitmap stream is sorted asc=true, but the seek expects asc=false")
+	}
+	if target > s.pos {
+		s.pos = min(target, len(s.words)*64)
+	}
+}
+
+// Words returns a copy of the bitmap of the remaining items
+func (s *BitmapStream) Words() []uint64 { return s.remaining() }
+
+// remaining copies the words with the bits of items already read cleared
+func (s *BitmapStream) remaining() []uint64 {
+	words := make([]uint64, len(s.words))
+	copy(words, s.words)
+	for i := 0; i < s.pos>>6 && i < len(words); i++ {
+		words[i] = 0
+	}
+	if i := s.pos >> 6; i < len(words) {
+		words[i] &^= 1<<(s.pos&63) - 1
+	}
+	return words
+}
+
+// skipAll marks every item as read
+func (s *BitmapStream) skipAll() { s.pos = len(s.words) * 64 }
+
+// IntersectBitmap is Intersect which ANDs the words when both operands are BitmapStreams (the result is a BitmapStream
+// then), which is much faster than merging items of dense sets. Otherwise, it falls back to Intersect
+func IntersectBitmap(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int] {
+	return bitmapOperation(stream1, stream2, asc, func(a, b uint64) uint64 { return a & b }, Intersect[int], opts)
+}
+
+// UnionBitmap is Union which ORs the words of BitmapStream operands (see IntersectBitmap)
+func UnionBitmap(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int] {
+	return bitmapOperation(stream1, stream2, asc, func(a, b uint64) uint64 { return a | b }, Union[int], opts)
+}
+
+// DiffBitmap is Diff which ANDNOTs the words of BitmapStream operands (see IntersectBitmap)
+func DiffBitmap(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int] {
+	return bitmapOperation(stream1, stream2, asc, func(a, b uint64) uint64 { return a &^ b }, Diff[int], opts)
+}
+
+func bitmapOperation(
+	stream1, stream2 SortedNumbersStream[int],
+	asc bool,
+	op func(a, b uint64) uint64,
+	fallback func(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int],
+	opts []Option,
+) SortedNumbersStream[int] {
+	bitmap1, ok1 := stream1.(*BitmapStream)
+	bitmap2, ok2 := stream2.(*BitmapStream)
+	if !ok1 || !ok2 || !asc || !newConfig(opts).allowsShortcuts() {
+		return fallback(stream1, stream2, asc, opts...)
+	}
+	a, b := bitmap1.remaining(), bitmap2.remaining()
+	bitmap1.skipAll()
+	bitmap2.skipAll()
+	words := make([]uint64, max(len(a), len(b)))
+	for i := range words {
+		var wordA, wordB uint64
+		if i < len(a) {
+			wordA = a[i]
+		}
+		if i < len(b) {
+			wordB = b[i]
+		}
+		words[i] = op(wordA, wordB)
+	}
+	return FromBitmap(words)
+}
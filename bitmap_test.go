@@ -0,0 +1,92 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBitmapStream(t *testing.T) {
+	s := NewBitmapStream([]int{130, 0, 5, 63, 64})
+	n, _ := s.Len()
+	require.Equal(t, 5, n)
+	require.EqualValues(t, []int{0, 5, 63, 64, 130}, ToSlice[int](s))
+
+	s = NewBitmapStream([]int{1, 70, 80, 200})
+	s.Seek(71, true)
+	n, _ = s.Len()
+	require.Equal(t, 2, n)
+	require.EqualValues(t, []int{80, 200}, ToSlice[int](s))
+	require.Panics(t, func() { s.Seek(0, false) })
+
+	require.EqualValues(t, []int{}, ToSlice[int](NewBitmapStream(nil)))
+	require.Panics(t, func() { NewBitmapStream([]int{-1}) })
+}
+
+func TestBitmapOperations(t *testing.T) {
+	type test struct {
+		a, b                         []int
+		union, intersect, difference []int
+	}
+	tests := []test{
+		{[]int{}, []int{}, []int{}, []int{}, []int{}},
+		{[]int{1, 2, 3}, []int{}, []int{1, 2, 3}, []int{}, []int{1, 2, 3}},
+		{[]int{1, 64, 65, 300}, []int{2, 65, 300, 400}, []int{1, 2, 64, 65, 300, 400}, []int{65, 300}, []int{1, 64}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			ops := []struct {
+				op     func(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int]
+				result []int
+			}{
+				{UnionBitmap, tt.union},
+				{IntersectBitmap, tt.intersect},
+				{DiffBitmap, tt.difference},
+			}
+			for _, op := range ops {
+				result := op.op(NewBitmapStream(tt.a), NewBitmapStream(tt.b), true)
+				require.IsType(t, &BitmapStream{}, result)
+				require.EqualValues(t, op.result, ToSlice(result))
+
+				// fallback to the merge
+				require.EqualValues(t, op.result, ToSlice(op.op(NewBitmapStream(tt.a), NewSliceStream(tt.b), true)))
+			}
+		})
+	}
+}
+
+func TestBitmapOperationsOfPartiallyReadOperands(t *testing.T) {
+	a, b := NewBitmapStream([]int{1, 2, 100}), NewBitmapStream([]int{1, 2, 100})
+	a.Next()
+	require.EqualValues(t, []int{2, 100}, ToSlice(IntersectBitmap(a, b, true)))
+	_, ok := a.Next()
+	require.False(t, ok) // operands are drained, like after the merge
+}
+
+// BenchmarkBitmapIntersect compares the word-by-word AND of dense bitmaps with the merge of their items
+func BenchmarkBitmapIntersect(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	var itemsA, itemsB []int
+	for i := 0; i < 100_000; i++ {
+		if r.Intn(2) == 0 {
+			itemsA = append(itemsA, i)
+		}
+		if r.Intn(2) == 0 {
+			itemsB = append(itemsB, i)
+		}
+	}
+
+	b.Run("bitmap", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToSlice(IntersectBitmap(NewBitmapStream(itemsA), NewBitmapStream(itemsB), true))
+		}
+	})
+	b.Run("merge", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ToSlice(Intersect[int](NewBitmapStream(itemsA), NewBitmapStream(itemsB), true, WithPullBackend()))
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// UpperBound emits items up to max inclusive and stops reading the stream at the first item past it,
+// e.g. for "ids up to X" queries. Bounds follow the stream order: a desc stream stops at the first item below max
+func UpperBound[T constraints.Ordered](stream SortedNumbersStream[T], max T, asc bool) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	mustMatchDirection(asc, stream)
+	return &directedStream[T]{&upperBoundStream[T]{stream: stream, max: max, asc: asc}, asc}
+}
+
+type upperBoundStream[T constraints.Ordered] struct {
+	stream    SortedNumbersStream[T]
+	max       T
+	exclusive bool // stop at max too
+	asc       bool
+	done      bool
+}
+
+func (s *upperBoundStream[T]) Next() (item T, ok bool) {
+	if s.done {
+		return
+	}
+	if item, ok = s.stream.Next(); ok && (s.past(item) || s.exclusive && compareOrdered(item, s.max) == 0) {
+		var zero T
+		item, ok = zero, false
+	}
+	s.done = !ok
+	return item, ok
+}
+
+func (s *upperBoundStream[T]) past(item T) bool {
+	return goesBefore(s.max, item, s.asc)
+}
+
+// LowerBound skips items before min, so the result starts with min or the next item in the stream order.
+// A desc stream skips items above min
+func LowerBound[T constraints.Ordered](stream SortedNumbersStream[T], min T, asc bool) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	mustMatchDirection(asc, stream)
+	return &directedStream[T]{&lowerBoundStream[T]{stream: stream, min: min, asc: asc}, asc}
+}
+
+type lowerBoundStream[T constraints.Ordered] struct {
+	stream  SortedNumbersStream[T]
+	min     T
+	asc     bool
+	skipped bool
+}
+
+func (s *lowerBoundStream[T]) Next() (item T, ok bool) {
+	for {
+		if item, ok = s.stream.Next(); !ok || s.skipped {
+			return
+		}
+		if !goesBefore(item, s.min, s.asc) {
+			s.skipped = true
+			return
+		}
+	}
+}
+
+// RangeQuery emits items from lo inclusive to hi exclusive, like a range scan of a sorted index.
+// Seekable streams skip the prefix with Seek (a binary search for SliceStream), others are read until lo.
+// Bounds follow the stream order: for a desc stream lo >= hi
+func RangeQuery[T constraints.Ordered](stream SortedNumbersStream[T], lo, hi T, asc bool) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	mustMatchDirection(asc, stream)
+	if seekable, ok := stream.(Seekable[T]); ok {
+		seekable.Seek(lo, asc)
+	} else {
+		stream = LowerBound(stream, lo, asc)
+	}
+	return &directedStream[T]{&upperBoundStream[T]{stream: stream, max: hi, exclusive: true, asc: asc}, asc}
+}
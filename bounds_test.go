@@ -0,0 +1,136 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpperBound(t *testing.T) {
+	type test struct {
+		items  []int
+		max    int
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 4}, 2, true, []int{1, 2}},
+		{[]int{1, 2, 4, 5}, 3, true, []int{1, 2}},
+		{[]int{1, 2}, 5, true, []int{1, 2}},
+		{[]int{1, 2}, 0, true, []int{}},
+		{[]int{}, 0, true, []int{}},
+		{[]int{4, 3, 2, 1}, 3, false, []int{4, 3}},
+		{[]int{4, 3, 1}, 2, false, []int{4, 3}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(UpperBound[int](NewSliceStream(tt.items), tt.max, tt.asc)))
+		})
+	}
+}
+
+func TestUpperBoundStopsEarly(t *testing.T) {
+	stream, history := Record[int](NewSliceStream([]int{1, 2, 3, 4, 5}))
+	result := UpperBound[int](stream, 2, true)
+	require.EqualValues(t, []int{1, 2}, ToSlice(result))
+	_, ok := result.Next()
+	require.False(t, ok)
+	require.EqualValues(t, []int{1, 2, 3}, history())
+}
+
+func TestLowerBound(t *testing.T) {
+	type test struct {
+		items  []int
+		min    int
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 4}, 3, true, []int{3, 4}},
+		{[]int{1, 2, 4, 5}, 3, true, []int{4, 5}},
+		{[]int{1, 2}, 0, true, []int{1, 2}},
+		{[]int{1, 2}, 5, true, []int{}},
+		{[]int{4, 3, 2, 1}, 2, false, []int{2, 1}},
+		{[]int{4, 3, 1}, 2, false, []int{1}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(LowerBound[int](NewSliceStream(tt.items), tt.min, tt.asc)))
+		})
+	}
+
+	// both bounds make a range
+	result := UpperBound[int](LowerBound[int](NewSliceStream([]int{1, 2, 3, 4, 5}), 2, true), 4, true)
+	require.EqualValues(t, []int{2, 3, 4}, ToSlice(result))
+}
+
+// linearStream hides Seek of the wrapped stream
+type linearStream[T any] struct {
+	SortedNumbersStream[T]
+}
+
+func TestRangeQuery(t *testing.T) {
+	type test struct {
+		items  []int
+		lo, hi int
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 4, 5}, 2, 4, true, []int{2, 3}}, // lo is inclusive, hi is exclusive
+		{[]int{1, 2, 4, 5}, 3, 5, true, []int{4}},
+		{[]int{1, 2, 3}, 0, 10, true, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, 2, 2, true, []int{}},
+		{[]int{1, 2, 3}, 5, 10, true, []int{}},
+		{[]int{1, 1, 2, 2, 3}, 1, 3, true, []int{1, 1, 2, 2}},
+		{[]int{5, 4, 3, 2, 1}, 4, 2, false, []int{4, 3}},
+		{[]int{5, 3, 1}, 4, 0, false, []int{3, 1}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			seeking := RangeQuery[int](NewSliceStream(tt.items), tt.lo, tt.hi, tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(seeking))
+
+			linear := RangeQuery[int](linearStream[int]{NewSliceStream(tt.items)}, tt.lo, tt.hi, tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(linear))
+		})
+	}
+}
+
+// nextCountingSlice counts items read with Next
+type nextCountingSlice struct {
+	*SliceStream[int]
+	reads int
+}
+
+func (s *nextCountingSlice) Next() (int, bool) {
+	s.reads++
+	return s.SliceStream.Next()
+}
+
+func TestRangeQuerySeeks(t *testing.T) {
+	stream := &nextCountingSlice{SliceStream: NewSliceStream(ToSlice[int](NewRangeStream(0, 1000, 1)))}
+	result := RangeQuery[int](stream, 500, 503, true)
+	require.EqualValues(t, []int{500, 501, 502}, ToSlice(result))
+	require.Equal(t, 4, stream.reads) // the prefix is skipped without reading
+}
+
+func TestBoundsNaN(t *testing.T) {
+	nan := math.NaN()
+	items := func() SortedNumbersStream[float64] { return NewSliceStream([]float64{nan, 1, 2, 3}) }
+	require.EqualValues(t, []float64{1, 2, 3}, ToSlice(LowerBound(items(), 1, true)))
+	require.Len(t, ToSlice(UpperBound(items(), 2, true)), 3) // NaN goes before 2
+	require.Len(t, ToSlice(LowerBound(items(), nan, true)), 4)
+}
+
+func TestRangeQueryNaN(t *testing.T) {
+	nan := math.NaN()
+	items := NewSliceStream([]float64{nan, nan, 1, 2})
+	require.EqualValues(t, []float64{}, ToSlice(RangeQuery[float64](items, nan, nan, true)))
+	require.Len(t, ToSlice(RangeQuery[float64](NewSliceStream([]float64{nan, nan, 1, 2}), nan, 1, true)), 2)
+}
@@ -0,0 +1,185 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"io"
+	"os"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// BufferedStream records items of the source as they are read, so the stream can be replayed with Reset
+// (e.g. a computed union used by several operations one after another).
+// The recording is kept in memory, with WithSpill it moves to a temp file once it grows over the threshold
+type BufferedStream[T constraints.Ordered] struct {
+	src       SortedNumbersStream[T]
+	recording bool // the source is not drained yet
+	buffer    []T
+	threshold int // in bytes, 0 keeps everything in memory
+	dir       string
+
+	spill       *os.File
+	spillWriter *ChannelStream[T] // feeds EncodeStream writing the spill file
+	spillDone   chan error
+	spillFailed bool // the spill file could not be written, the recording is incomplete
+
+	replay SortedNumbersStream[T] // set by Reset
+	err    error
+}
+
+// NewBufferedStream returns a replayable stream of the source, see WithSpill to cap its memory
+func NewBufferedStream[T constraints.Ordered](src SortedNumbersStream[T], opts ...BufferOption) *BufferedStream[T] {
+	cfg := bufferConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return &BufferedStream[T]{src: orEmpty(src), recording: true, threshold: cfg.spillThreshold, dir: cfg.spillDir}
+}
+
+// BufferOption configures NewBufferedStream
+type BufferOption func(*bufferConfig)
+
+type bufferConfig struct {
+	spillThreshold int
+	spillDir       string
+}
+
+// WithSpill moves the recording to a temp file in dir (os.TempDir if empty) once the items take more than
+// thresholdBytes of memory (the size of string contents is not counted).
+// Integer streams are spilled in the compact delta-varint encoding (see EncodeStream)
+func WithSpill(thresholdBytes int, dir string) BufferOption {
+	return func(cfg *bufferConfig) {
+		cfg.spillThreshold = thresholdBytes
+		cfg.spillDir = dir
+	}
+}
+
+func (s *BufferedStream[T]) Next() (item T, ok bool) {
+	if s.replay != nil {
+		if item, ok = s.replay.Next(); !ok {
+			if e, isErrorable := s.replay.(Errorable); isErrorable && e.Err() != nil && s.err == nil {
+				s.err = e.Err()
+			}
+		}
+		return
+	}
+	if !s.recording {
+		return item, false
+	}
+	if item, ok = s.src.Next(); !ok {
+		s.finishRecording()
+		return
+	}
+	s.record(item)
+	return item, true
+}
+
+// Reset starts the stream over. Items not read from the source yet are recorded first,
+// a spilled recording is read back from the disk. If writing the spill file failed, the replay is empty and Err reports why
+func (s *BufferedStream[T]) Reset() {
+	for s.recording {
+		item, ok := s.src.Next()
+		if !ok {
+			s.finishRecording()
+			break
+		}
+		s.record(item)
+	}
+	if s.spill == nil {
+		s.replay = NewSliceStream(s.buffer)
+		return
+	}
+	if s.spillFailed {
+		s.replay = emptyStream[T]{}
+		return
+	}
+	if _, err := s.spill.Seek(0, io.SeekStart); err != nil {
+		s.err = err
+		s.replay = emptyStream[T]{}
+		return
+	}
+	s.replay = DecodeStream[T](s.spill)
+}
+
+// Spilled tells if the recording was moved to a temp file
+func (s *BufferedStream[T]) Spilled() bool { return s.spill != nil }
+
+// Err returns the error of the source, of the spill file or of reading it back
+func (s *BufferedStream[T]) Err() error { return s.err }
+
+// Close removes the spill file, the stream can't be replayed afterwards
+func (s *BufferedStream[T]) Close() error {
+	if s.spill == nil {
+		return nil
+	}
+	if s.recording {
+		s.finishRecording()
+	}
+	err := errors.Join(s.spill.Close(), os.Remove(s.spill.Name()))
+	s.spill, s.replay, s.recording = nil, emptyStream[T]{}, false
+	return err
+}
+
+func (s *BufferedStream[T]) record(item T) {
+	if s.spillFailed {
+		return
+	}
+	if s.spillWriter != nil {
+		s.spillItem(item)
+		return
+	}
+	s.buffer = append(s.buffer, item)
+	if s.threshold > 0 && len(s.buffer)*int(unsafe.Sizeof(item)) > s.threshold {
+		s.startSpill()
+	}
+}
+
+// startSpill moves the buffer to a temp file, further items are appended to it.
+// If the file can't be created the recording stays in memory and Err reports the failure
+func (s *BufferedStream[T]) startSpill() {
+	f, err := os.CreateTemp(s.dir, "stream-spill-*")
+	if err != nil {
+		s.err, s.threshold = err, 0
+		return
+	}
+	s.spill = f
+	s.spillWriter = &ChannelStream[T]{pipe: make(chan T, encodeBlockSize)}
+	s.spillDone = make(chan error, 1)
+	go func() { s.spillDone <- EncodeStream[T](s.spillWriter, f) }()
+	for _, item := range s.buffer {
+		if !s.spillItem(item) {
+			break
+		}
+	}
+	s.buffer = nil
+}
+
+// spillItem passes the item to the spill encoder. If the encoder failed, it stops reading the items,
+// so the recording stops and Err reports the failure while the source is still read through
+func (s *BufferedStream[T]) spillItem(item T) bool {
+	select {
+	case s.spillWriter.pipe <- item:
+		return true
+	case err := <-s.spillDone:
+		if s.err == nil {
+			s.err = err
+		}
+		s.spillWriter, s.spillFailed = nil, true
+		return false
+	}
+}
+
+func (s *BufferedStream[T]) finishRecording() {
+	s.recording = false
+	if e, ok := s.src.(Errorable); ok && e.Err() != nil {
+		s.err = e.Err()
+	}
+	if s.spillWriter != nil {
+		s.spillWriter.Close()
+		if err := <-s.spillDone; err != nil && s.err == nil {
+			s.err = err
+		}
+		s.spillWriter = nil
+	}
+}
@@ -0,0 +1,74 @@
+package sorted_numeric_streams
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBufferedStream(t *testing.T) {
+	s := NewBufferedStream[int](NewSliceStream([]int{1, 2, 3}))
+	item, _ := s.Next()
+	require.Equal(t, 1, item)
+
+	s.Reset() // replays from the start, the rest of the source is recorded
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice[int](s))
+	s.Reset()
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice[int](s))
+	require.False(t, s.Spilled())
+	require.NoError(t, s.Err())
+	require.NoError(t, s.Close())
+}
+
+func TestBufferedStreamSpill(t *testing.T) {
+	dir := t.TempDir()
+	s := NewBufferedStream[int](NewRangeStream(0, 10_000, 1), WithSpill(1024, dir))
+	require.Len(t, ToSlice[int](s), 10_000)
+	require.True(t, s.Spilled())
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	info, err := files[0].Info()
+	require.NoError(t, err)
+	require.True(t, info.Size() < 20_000) // delta-varint takes a byte per dense item
+
+	for i := 0; i < 2; i++ {
+		s.Reset()
+		require.EqualValues(t, ToSlice[int](NewRangeStream(0, 10_000, 1)), ToSlice[int](s))
+		require.NoError(t, s.Err())
+	}
+
+	require.NoError(t, s.Close())
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 0)
+}
+
+func TestBufferedStreamSpillFailure(t *testing.T) {
+	s := NewBufferedStream[int](NewRangeStream(0, 100_000, 1), WithSpill(8, t.TempDir()))
+	read := 0
+	for ; !s.Spilled(); read++ {
+		s.Next()
+	}
+	require.NoError(t, s.spill.Close()) // the encoder fails on the next flush of its buffer
+
+	read += len(ToSlice[int](s)) // the source is read through, the reader is not blocked by the failed spill
+	require.Equal(t, 100_000, read)
+	require.ErrorIs(t, s.Err(), os.ErrClosed)
+
+	s.Reset()
+	require.EqualValues(t, []int{}, ToSlice[int](s))
+	s.Close()
+}
+
+func TestBufferedStreamSpillStrings(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	s := NewBufferedStream[string](NewSliceStream(items), WithSpill(1, t.TempDir()))
+	s.Next()
+	s.Reset()
+	require.True(t, s.Spilled())
+	require.EqualValues(t, items, ToSlice[string](s))
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,99 @@
+package sorted_numeric_streams
+
+import (
+	"container/list"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Cache memoizes materialized results of operations by a caller-provided key (e.g. a normalized query),
+// so repeated queries don't recompute them. It holds up to maxItems items in total, evicting the least recently
+// used results. It is safe for concurrent use, concurrent misses of the same key compute the result each
+type Cache[K comparable, T constraints.Ordered] struct {
+	mu       sync.Mutex
+	maxItems int
+	items    int        // cached items in total
+	lru      *list.List // of *cacheEntry, the most recently used first
+	entries  map[K]*list.Element
+}
+
+type cacheEntry[K comparable, T constraints.Ordered] struct {
+	key   K
+	items []T
+}
+
+// NewCache returns a cache holding up to maxItems items of all results
+func NewCache[K comparable, T constraints.Ordered](maxItems int) *Cache[K, T] {
+	return &Cache[K, T]{maxItems: maxItems, lru: list.New(), entries: make(map[K]*list.Element)}
+}
+
+// Cached returns a fresh stream of the result cached under the key, compute makes it on a miss.
+// A result is not cached if it is larger than the cache or if its stream reports an error (see Errorable).
+// Streams of a cached result share its memory, they copy items out and never hand out the cached slice
+func (c *Cache[K, T]) Cached(key K, compute func() SortedNumbersStream[T]) SortedNumbersStream[T] {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		items := e.Value.(*cacheEntry[K, T]).items
+		c.mu.Unlock()
+		return &cachedStream[T]{NewSliceStream(items)}
+	}
+	c.mu.Unlock()
+
+	result := compute()
+	items := AppendTo(nil, orEmpty(result)) // a copy, the cache must not share memory with the source
+	if e, ok := result.(Errorable); ok && e.Err() != nil {
+		return &failedStream[T]{NewSliceStream(items), e.Err()}
+	}
+	c.store(key, items)
+	return &cachedStream[T]{NewSliceStream(items)}
+}
+
+// Len returns the number of cached results
+func (c *Cache[K, T]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache[K, T]) store(key K, items []T) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(items) > c.maxItems {
+		return
+	}
+	if e, ok := c.entries[key]; ok { // computed concurrently
+		c.remove(e)
+	}
+	c.entries[key] = c.lru.PushFront(&cacheEntry[K, T]{key, items})
+	c.items += len(items)
+	for c.items > c.maxItems {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *Cache[K, T]) remove(e *list.Element) {
+	entry := c.lru.Remove(e).(*cacheEntry[K, T])
+	delete(c.entries, entry.key)
+	c.items -= len(entry.items)
+}
+
+// cachedStream reads a cached result without exposing its slice: unlike SliceStream it is not drained
+// by handing out the backing array (see ToSlice), so callers modifying what they read don't corrupt the cache
+type cachedStream[T constraints.Ordered] struct {
+	items *SliceStream[T]
+}
+
+func (s *cachedStream[T]) Next() (T, bool)           { return s.items.Next() }
+func (s *cachedStream[T]) NextN(buf []T) (int, bool) { return s.items.NextN(buf) }
+func (s *cachedStream[T]) Len() (int, bool)          { return s.items.Len() }
+func (s *cachedStream[T]) Seek(target T, asc bool)   { s.items.Seek(target, asc) }
+
+// failedStream replays items read before the failure and reports the error
+type failedStream[T any] struct {
+	SortedNumbersStream[T]
+	err error
+}
+
+func (s *failedStream[T]) Err() error { return s.err }
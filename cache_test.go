@@ -0,0 +1,71 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache[string, int](5)
+	computed := 0
+	query := func(items ...int) func() SortedNumbersStream[int] {
+		return func() SortedNumbersStream[int] {
+			computed++
+			return Union[int](NewSliceStream(items), NewSliceStream([]int{}), true)
+		}
+	}
+
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(cache.Cached("a", query(1, 2, 3)))) // miss
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(cache.Cached("a", query(1, 2, 3)))) // hit
+	require.Equal(t, 1, computed)
+
+	first, second := cache.Cached("a", query()), cache.Cached("a", query())
+	require.EqualValues(t, ToSlice(first), ToSlice(second)) // every hit is a fresh stream
+	require.Equal(t, 1, computed)
+
+	cache.Cached("b", query(4, 5))
+	cache.Cached("a", query()) // a is used more recently than b
+	cache.Cached("c", query(6))
+	require.Equal(t, 2, cache.Len()) // 6 items do not fit, b is evicted
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(cache.Cached("a", query())))
+	require.EqualValues(t, []int{4, 5}, ToSlice(cache.Cached("b", query(4, 5))))
+	require.Equal(t, 4, computed)
+}
+
+func TestCacheSkipsLargeAndFailedResults(t *testing.T) {
+	cache := NewCache[int, int](2)
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(cache.Cached(1, func() SortedNumbersStream[int] { return NewSliceStream([]int{1, 2, 3}) })))
+	require.Equal(t, 0, cache.Len())
+
+	failure := errors.New("disk is gone")
+	result := cache.Cached(2, func() SortedNumbersStream[int] {
+		return &failedStream[int]{NewSliceStream([]int{1}), failure}
+	})
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.ErrorIs(t, result.(Errorable).Err(), failure)
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestCacheDoesNotShareSourceMemory(t *testing.T) {
+	cache := NewCache[int, int](10)
+	source := []int{1, 2}
+	cache.Cached(1, func() SortedNumbersStream[int] { return NewSliceStream(source) })
+	source[0] = 100
+	require.EqualValues(t, []int{1, 2}, ToSlice(cache.Cached(1, nil)))
+}
+
+func TestCacheResultsCanBeModified(t *testing.T) {
+	cache := NewCache[string, int](10)
+	compute := func() SortedNumbersStream[int] { return NewSliceStream([]int{1, 2, 3}) }
+
+	miss := ToSlice(cache.Cached("a", compute))
+	miss[0] = 100
+	hit := ToSlice(cache.Cached("a", compute))
+	hit[1] = 200
+	stream := cache.Cached("a", compute)
+	IntersectInPlace(NewSliceStream(ToSlice(stream)), NewSliceStream([]int{3}), true)
+
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(cache.Cached("a", compute)))
+}
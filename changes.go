@@ -0,0 +1,74 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// ChangeKind classifies a key of two snapshots
+type ChangeKind int
+
+const (
+	Added     ChangeKind = iota + 1 // only in the new snapshot
+	Removed                         // only in the old snapshot
+	Modified                        // in both, records differ
+	Unchanged                       // in both, records are equal
+)
+
+// Change is a key of two snapshots, Old is zero for Added records and New is zero for Removed ones
+type Change[T any] struct {
+	Kind     ChangeKind
+	Old, New T
+}
+
+// DiffRecords compares two snapshots of records sorted by a unique key, like a table diff:
+// every key is classified as Added, Removed, Modified or Unchanged (equal tells if records of a key are the same).
+// WithoutUnchanged leaves Unchanged keys out. The diff is pull-based and uses no goroutines
+func DiffRecords[T any, K constraints.Ordered](old, new SortedNumbersStream[T], key func(T) K, equal func(a, b T) bool, asc bool, opts ...DiffRecordsOption) SortedNumbersStream[Change[T]] {
+	old, new = orEmpty(old), orEmpty(new)
+	cfg := diffRecordsConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	mustMatchDirection(asc, old, new)
+	cmp := func(a, b T) int { return compareOrdered(key(a), key(b)) }
+	return &changesStream[T]{
+		merger:        newMerger(old, new, cmp, unionStop, asc),
+		equal:         equal,
+		skipUnchanged: cfg.skipUnchanged,
+	}
+}
+
+// DiffRecordsOption configures DiffRecords
+type DiffRecordsOption func(*diffRecordsConfig)
+
+type diffRecordsConfig struct {
+	skipUnchanged bool
+}
+
+// WithoutUnchanged makes DiffRecords emit only changed keys
+func WithoutUnchanged() DiffRecordsOption {
+	return func(cfg *diffRecordsConfig) { cfg.skipUnchanged = true }
+}
+
+type changesStream[T any] struct {
+	merger        *merger[T]
+	equal         func(a, b T) bool
+	skipUnchanged bool
+}
+
+func (s *changesStream[T]) Next() (change Change[T], ok bool) {
+	for {
+		a, b, ok := s.merger.next()
+		if !ok {
+			return change, false
+		}
+		switch {
+		case a == nil:
+			return Change[T]{Kind: Added, New: *b}, true
+		case b == nil:
+			return Change[T]{Kind: Removed, Old: *a}, true
+		case !s.equal(*a, *b):
+			return Change[T]{Kind: Modified, Old: *a, New: *b}, true
+		case !s.skipUnchanged:
+			return Change[T]{Kind: Unchanged, Old: *a, New: *b}, true
+		}
+	}
+}
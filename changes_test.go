@@ -0,0 +1,39 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffRecords(t *testing.T) {
+	old := func() SortedNumbersStream[record] {
+		return newRecordStream(record{1, "a"}, record{2, "b"}, record{3, "c"}, record{5, "e"})
+	}
+	new := func() SortedNumbersStream[record] {
+		return newRecordStream(record{2, "b"}, record{3, "C"}, record{4, "d"}, record{5, "e"})
+	}
+	equal := func(a, b record) bool { return a == b }
+
+	changes := ToSlice(DiffRecords(old(), new(), recordID, equal, true))
+	require.EqualValues(t, []Change[record]{
+		{Kind: Removed, Old: record{1, "a"}},
+		{Kind: Unchanged, Old: record{2, "b"}, New: record{2, "b"}},
+		{Kind: Modified, Old: record{3, "c"}, New: record{3, "C"}},
+		{Kind: Added, New: record{4, "d"}},
+		{Kind: Unchanged, Old: record{5, "e"}, New: record{5, "e"}},
+	}, changes)
+
+	changes = ToSlice(DiffRecords(old(), new(), recordID, equal, true, WithoutUnchanged()))
+	require.EqualValues(t, []Change[record]{
+		{Kind: Removed, Old: record{1, "a"}},
+		{Kind: Modified, Old: record{3, "c"}, New: record{3, "C"}},
+		{Kind: Added, New: record{4, "d"}},
+	}, changes)
+}
+
+func TestDiffRecordsEmptySnapshot(t *testing.T) {
+	equal := func(a, b record) bool { return a == b }
+	changes := ToSlice(DiffRecords(nil, newRecordStream(record{1, "a"}), recordID, equal, true))
+	require.EqualValues(t, []Change[record]{{Kind: Added, New: record{1, "a"}}}, changes)
+}
@@ -0,0 +1,98 @@
+package sorted_numeric_streams
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/exp/constraints"
+)
+
+// CommColumn tells which streams contain an element, like the three columns of Unix `comm`
+type CommColumn int
+
+const (
+	OnlyInA CommColumn = iota + 1
+	OnlyInB
+	InBoth
+)
+
+// CommRow is an element tagged with the streams it was found in
+type CommRow[T constraints.Ordered] struct {
+	Value  T
+	Column CommColumn
+}
+
+// String renders the row as `comm` does: column 1 is not indented, columns 2 and 3 are indented with 1 and 2 tabs
+func (r CommRow[T]) String() string {
+	return fmt.Sprintf("%s%v", strings.Repeat("\t", int(r.Column)-1), r.Value)
+}
+
+// Comm returns every element of stream1 and stream2 tagged as only-in-A, only-in-B or in-both (like Unix `comm`)
+func Comm[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[CommRow[T]] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	result := NewChannelStream[CommRow[T]]()
+	commOperation := func(a, b *T) {
+		if a != nil && b != nil {
+			result.Push(CommRow[T]{*a, InBoth})
+		} else if a != nil {
+			result.Push(CommRow[T]{*a, OnlyInA})
+		} else {
+			result.Push(CommRow[T]{*b, OnlyInB})
+		}
+	}
+
+	go func() {
+		defer result.closeOnPanic()
+		iterate(stream1, stream2, commOperation, unionStop, asc)
+		result.Close()
+	}()
+
+	return result
+}
+
+// WriteComm drains rows and writes them to w line by line in `comm` format
+func WriteComm[T constraints.Ordered](w io.Writer, rows SortedNumbersStream[CommRow[T]]) error {
+	bw := bufio.NewWriter(w)
+	for {
+		row, ok := rows.Next()
+		if !ok {
+			break
+		}
+		if _, err := fmt.Fprintln(bw, row); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// MembershipMask emits a flag per item of stream1, in its order: true if the item is in stream2 as well
+// (e.g. is this ID whitelisted). It is pull-based and reads stream2 only as far as the current item of stream1
+func MembershipMask[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[bool] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	return &maskStream[T]{a: stream1, b: stream2, asc: asc}
+}
+
+type maskStream[T constraints.Ordered] struct {
+	a, b     SortedNumbersStream[T]
+	asc      bool
+	head     T // the first item of stream2 not going before the current item of stream1
+	hasHead  bool
+	drainedB bool
+}
+
+func (s *maskStream[T]) Next() (found bool, ok bool) {
+	item, ok := s.a.Next()
+	if !ok {
+		return false, false
+	}
+	for !s.drainedB && (!s.hasHead || goesBefore(s.head, item, s.asc)) {
+		if s.head, s.hasHead = s.b.Next(); !s.hasHead {
+			s.drainedB = true
+		}
+	}
+	return s.hasHead && compareOrdered(s.head, item) == 0, true
+}
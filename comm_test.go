@@ -0,0 +1,66 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComm(t *testing.T) {
+	a := NewSliceStream([]int{1, 2, 4})
+	b := NewSliceStream([]int{2, 3, 4, 5})
+	rows := ToSlice(Comm[int](a, b, true))
+	require.EqualValues(t, []CommRow[int]{
+		{1, OnlyInA},
+		{2, InBoth},
+		{3, OnlyInB},
+		{4, InBoth},
+		{5, OnlyInB},
+	}, rows)
+}
+
+func TestWriteComm(t *testing.T) {
+	a := NewLinesStream(strings.NewReader("apple\nbanana\n"))
+	b := NewLinesStream(strings.NewReader("banana\ncherry\n"))
+	var out strings.Builder
+	require.NoError(t, WriteComm(&out, Comm[string](a, b, true)))
+	require.Equal(t, "apple\n\t\tbanana\n\tcherry\n", out.String())
+}
+
+func TestMembershipMask(t *testing.T) {
+	type test struct {
+		a, b []int
+		asc  bool
+		mask []bool
+	}
+	tests := []test{
+		{[]int{}, []int{1}, true, []bool{}},
+		{[]int{1, 2}, []int{}, true, []bool{false, false}},
+		{[]int{1, 2, 4, 7}, []int{0, 2, 3, 7, 9}, true, []bool{false, true, false, true}},
+		{[]int{7, 4, 2, 1}, []int{9, 7, 3, 2}, false, []bool{true, false, true, false}},
+		{[]int{1, 3, 3, 5}, []int{3}, true, []bool{false, true, true, false}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.mask, ToSlice(MembershipMask[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)))
+		})
+	}
+}
+
+func TestMembershipMaskReadsBAsNeeded(t *testing.T) {
+	b := NewSliceStream([]int{1, 2, 3, 10, 11})
+	mask := MembershipMask[int](NewSliceStream([]int{2, 3}), b, true)
+	require.EqualValues(t, []bool{true, true}, ToSlice(mask))
+	require.EqualValues(t, []int{10, 11}, ToSlice[int](b))
+}
+
+func TestMembershipMaskNaN(t *testing.T) {
+	nan := math.NaN()
+	a := NewSliceStream([]float64{nan, 1, 2})
+	b := NewSliceStream([]float64{nan, 2})
+	require.EqualValues(t, []bool{true, false, true}, ToSlice(MembershipMask[float64](a, b, true)))
+}
@@ -0,0 +1,182 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"io"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Composer builds expressions of set operations evaluated lazily in the goroutine that reads the result (pull model).
+// Unlike Union/Intersect/Diff, a composed expression of any depth uses no goroutines or channels,
+// which matters for query engines building large boolean expression trees.
+// The direction is set once for the whole expression.
+type Composer[T constraints.Ordered] struct {
+	asc bool
+}
+
+// PullResult is the result of a lazy operation, it can be abandoned before it is drained:
+// Close stops the operation and closes the operands, so nothing is leaked by reading only a prefix
+type PullResult[T any] interface {
+	DirectedStream[T]
+	// Close stops the operation and closes operands implementing io.Closer (e.g. nested PullResults, file-backed streams),
+	// Next returns ok=false afterwards
+	Close() error
+}
+
+// Compose returns a builder of pull-based operations for streams in the given direction
+//
+//	op := Compose[int](true)
+//	result := op.Diff(op.Intersect(a, b), c) // (a and b) and not c
+func Compose[T constraints.Ordered](asc bool) *Composer[T] {
+	return &Composer[T]{asc: asc}
+}
+
+// Union returns the lazy stream of elements that are either in stream1 or stream2
+func (c *Composer[T]) Union(stream1, stream2 SortedNumbersStream[T]) PullResult[T] {
+	return newPullOperation(stream1, stream2, unionPick[T], unionStop, c.asc)
+}
+
+// Intersect returns the lazy stream of elements that are in both stream1 and stream2
+func (c *Composer[T]) Intersect(stream1, stream2 SortedNumbersStream[T]) PullResult[T] {
+	return newPullOperation(stream1, stream2, intersectPick[T], intersectStop, c.asc)
+}
+
+// Diff returns the lazy stream of elements that are in stream1 but not in stream2 (see Diff)
+func (c *Composer[T]) Diff(stream1, stream2 SortedNumbersStream[T]) PullResult[T] {
+	return newPullOperation(stream1, stream2, newDiffPick[T](), diffStop, c.asc)
+}
+
+// pullOperation computes the next item of the operation on demand
+type pullOperation[T constraints.Ordered] struct {
+	merger *merger[T]
+	pick   picker[T]
+	asc    bool
+	onDone func() // called once the merge is finished, may be nil
+}
+
+func newPullOperation[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], pick picker[T], stop shouldStop, asc bool) *pullOperation[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	return &pullOperation[T]{
+		merger: newMerger(stream1, stream2, compareOrdered[T], stop, asc),
+		pick:   pick,
+		asc:    asc,
+	}
+}
+
+func (s *pullOperation[T]) Next() (item T, ok bool) {
+	for {
+		a, b, ok := s.merger.next()
+		if !ok {
+			if s.onDone != nil {
+				s.onDone()
+				s.onDone = nil
+			}
+			return item, false
+		}
+		if picked := s.pick(a, b); picked != nil {
+			return *picked, true
+		}
+	}
+}
+
+func (s *pullOperation[T]) Asc() bool { return s.asc }
+
+func (s *pullOperation[T]) Close() error {
+	s.merger.done = true
+	if s.onDone != nil {
+		s.onDone()
+		s.onDone = nil
+	}
+	var errs []error
+	for _, operand := range []SortedNumbersStream[T]{s.merger.stream1, s.merger.stream2} {
+		if closer, ok := operand.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// StopAfter emits at most k items of the stream (e.g. a page of search results) and closes it right after the k-th one,
+// so a composed expression (see Compose) stops reading its sources and releases them as soon as the page is ready.
+// The result can be closed early too, it keeps the direction of the stream. Err returns the error of closing it
+func StopAfter[T any](stream SortedNumbersStream[T], k int) SortedNumbersStream[T] {
+	s := &stopAfterStream[T]{stream: orEmpty(stream), left: k}
+	if k <= 0 {
+		s.Close()
+	}
+	if d, ok := stream.(DirectedStream[T]); ok {
+		return &directedStopAfter[T]{s, d.Asc()}
+	}
+	return s
+}
+
+type stopAfterStream[T any] struct {
+	stream SortedNumbersStream[T]
+	left   int // items to emit
+	closed bool
+	err    error
+}
+
+func (s *stopAfterStream[T]) Next() (item T, ok bool) {
+	if s.closed {
+		return
+	}
+	if item, ok = s.stream.Next(); !ok {
+		return
+	}
+	if s.left--; s.left == 0 {
+		s.Close()
+	}
+	return item, true
+}
+
+// Close closes the stream if it is an io.Closer, only once
+func (s *stopAfterStream[T]) Close() error {
+	if s.closed {
+		return s.err
+	}
+	s.closed = true
+	if closer, ok := s.stream.(io.Closer); ok {
+		s.err = closer.Close()
+	}
+	return s.err
+}
+
+func (s *stopAfterStream[T]) Err() error { return s.err }
+
+type directedStopAfter[T any] struct {
+	*stopAfterStream[T]
+	asc bool
+}
+
+func (s *directedStopAfter[T]) Asc() bool { return s.asc }
+
+// IntersectWithLeftovers is Intersect which also returns what remains of the operands once the intersection stops
+// (it stops as soon as either operand is drained). Leftovers include an item read by the merge but not used,
+// so together with the consumed items they are exactly the operands. Leftovers are meant to be read after
+// the result is drained: reading them earlier takes items away from the intersection.
+// The result is pull-based and uses no goroutines
+func IntersectWithLeftovers[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) (result, leftover1, leftover2 SortedNumbersStream[T]) {
+	op := newPullOperation(stream1, stream2, intersectPick[T], intersectStop, asc)
+	m := op.merger
+	return op,
+		&directedStream[T]{&leftoverStream[T]{held: &m.i1, has: &m.has1, rest: m.stream1}, asc},
+		&directedStream[T]{&leftoverStream[T]{held: &m.i2, has: &m.has2, rest: m.stream2}, asc}
+}
+
+// leftoverStream yields the item held by the merge (if any) before the rest of the operand
+type leftoverStream[T any] struct {
+	held *T
+	has  *bool
+	rest SortedNumbersStream[T]
+}
+
+func (s *leftoverStream[T]) Next() (item T, ok bool) {
+	if *s.has {
+		*s.has = false
+		return *s.held, true
+	}
+	return s.rest.Next()
+}
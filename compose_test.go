@@ -0,0 +1,145 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompose(t *testing.T) {
+	type test struct {
+		a, b, c []int
+		asc     bool
+	}
+	tests := []test{
+		{[]int{}, []int{}, []int{}, true},
+		{[]int{1, 2, 3}, []int{2, 3}, []int{3}, true},
+		{[]int{1, 2, 3, 4, 5}, []int{0, 2, 4, 5}, []int{1, 5, 6}, true},
+		{[]int{5, 4, 3, 2, 1}, []int{5, 4, 2, 0}, []int{6, 5, 1}, false},
+		{[]int{1, 1, 2}, []int{1, 1, 2}, []int{1}, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := func(items []int) SortedNumbersStream[int] { return NewSliceStream(items) }
+			// expression: (a and b) and not c, or c
+			expected := ToSlice(Union[int](Diff[int](Intersect[int](s(tt.a), s(tt.b), tt.asc), s(tt.c), tt.asc), s(tt.c), tt.asc))
+
+			c := Compose[int](tt.asc)
+			actual := ToSlice(c.Union(c.Diff(c.Intersect(s(tt.a), s(tt.b)), s(tt.c)), s(tt.c)))
+			require.EqualValues(t, expected, actual)
+		})
+	}
+}
+
+func TestComposeUsesNoGoroutines(t *testing.T) {
+	c := Compose[int](true)
+	var expression SortedNumbersStream[int] = NewRangeStream(0, 1000, 1)
+	for i := 0; i < 100; i++ {
+		expression = c.Intersect(expression, NewRangeStream(i, 1000, 1))
+	}
+
+	before := runtime.NumGoroutine()
+	item, ok := expression.Next()
+	require.True(t, ok)
+	require.Equal(t, 99, item)
+	require.Equal(t, before, runtime.NumGoroutine())
+	require.Len(t, ToSlice(expression), 900)
+}
+
+func TestIntersectWithLeftovers(t *testing.T) {
+	type test struct {
+		a, b                 []int
+		asc                  bool
+		result, restA, restB []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3}, []int{1}, true, []int{1}, []int{2, 3}, []int{}},
+		{[]int{1}, []int{1, 2, 3}, true, []int{1}, []int{}, []int{2, 3}},
+		{[]int{1, 5, 6}, []int{2, 3, 5}, true, []int{5}, []int{6}, []int{}},
+		{[]int{1, 3}, []int{2, 4, 5}, true, []int{}, []int{}, []int{4, 5}},
+		{[]int{3, 2, 1}, []int{3}, false, []int{3}, []int{2, 1}, []int{}},
+		{[]int{}, []int{1, 2}, true, []int{}, []int{}, []int{1, 2}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			result, restA, restB := IntersectWithLeftovers[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(result))
+			require.EqualValues(t, tt.restA, ToSlice(restA))
+			require.EqualValues(t, tt.restB, ToSlice(restB))
+		})
+	}
+}
+
+// closeCounter counts Close calls of the stream
+type closeCounter struct {
+	SortedNumbersStream[int]
+	closed int
+	err    error
+}
+
+func (s *closeCounter) Close() error {
+	s.closed++
+	return s.err
+}
+
+func TestPullResultClose(t *testing.T) {
+	a := &closeCounter{SortedNumbersStream: NewRangeStream(0, 1_000_000, 1)}
+	b := &closeCounter{SortedNumbersStream: NewRangeStream(0, 1_000_000, 2)}
+	c := &closeCounter{SortedNumbersStream: NewRangeStream(0, 1_000_000, 3)}
+
+	op := Compose[int](true)
+	result := op.Union(op.Intersect(a, b), c)
+	for i := 0; i < 3; i++ { // take a prefix
+		_, ok := result.Next()
+		require.True(t, ok)
+	}
+
+	require.NoError(t, result.Close())
+	require.Equal(t, []int{1, 1, 1}, []int{a.closed, b.closed, c.closed}) // nested operations are closed too
+	_, ok := result.Next()
+	require.False(t, ok)
+}
+
+func TestPullResultCloseReportsErrors(t *testing.T) {
+	failure := errors.New("already closed")
+	file := &closeCounter{SortedNumbersStream: NewSliceStream([]int{1}), err: failure}
+	result := Compose[int](true).Diff(NewSliceStream([]int{1}), file)
+	require.ErrorIs(t, result.Close(), failure)
+}
+
+func TestStopAfter(t *testing.T) {
+	ranges := []*RangeStream[int]{NewRangeStream(0, 1_000_000, 1), NewRangeStream(0, 1_000_000, 2), NewRangeStream(0, 1_000_000, 3)}
+	a := &closeCounter{SortedNumbersStream: ranges[0]}
+	b := &closeCounter{SortedNumbersStream: ranges[1]}
+	c := &closeCounter{SortedNumbersStream: ranges[2]}
+
+	op := Compose[int](true)
+	page := StopAfter[int](op.Diff(op.Intersect(a, b), c), 3)
+	require.EqualValues(t, []int{2, 4, 8}, ToSlice(page))
+	require.Equal(t, []int{1, 1, 1}, []int{a.closed, b.closed, c.closed}) // closed once the page is ready
+
+	for i, r := range ranges {
+		n, _ := r.Len()
+		require.True(t, n > 1_000_000/(i+1)-10, "sources are not over-consumed")
+	}
+
+	require.NoError(t, page.(io.Closer).Close()) // closing again is fine
+	require.Equal(t, 1, a.closed)
+	require.True(t, page.(DirectedStream[int]).Asc())
+}
+
+func TestStopAfterReportsErrors(t *testing.T) {
+	failure := errors.New("already closed")
+	file := &closeCounter{SortedNumbersStream: NewSliceStream([]int{1, 2}), err: failure}
+	page := StopAfter[int](file, 1)
+	require.EqualValues(t, []int{1}, ToSlice(page))
+	require.ErrorIs(t, page.(Errorable).Err(), failure)
+
+	require.EqualValues(t, []int{}, ToSlice(StopAfter[int](NewSliceStream([]int{1}), 0)))
+}
@@ -0,0 +1,165 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// Estimate returns cardinalities of all set operations on stream1 and stream2 in a single pass:
+// |A or B|, |A and B|, |A not B|, |B not A|
+// Useful for query planning (e.g. choosing the order of operations)
+func Estimate[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) (unionN, interN, diffAN, diffBN int) {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	countOperation := func(a, b *T) {
+		unionN++
+		if a != nil && b != nil {
+			interN++
+		} else if a != nil {
+			diffAN++
+		} else {
+			diffBN++
+		}
+	}
+	iterate(stream1, stream2, countOperation, unionStop, asc)
+	return
+}
+
+// DiffCount returns |A not B| in a single pass, without allocating the result or running a goroutine
+// Unlike Estimate, it stops reading once stream1 is drained
+func DiffCount[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) (n int) {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	pick := newDiffPick[T]()
+	countOperation := func(a, b *T) {
+		if pick(a, b) != nil {
+			n++
+		}
+	}
+	iterate(stream1, stream2, countOperation, diffStop, asc)
+	return
+}
+
+// SymDiffCount returns |A xor B| = |A| + |B| - 2|A and B| in a single pass, without allocating the result
+// or running a goroutine, e.g. as a distance between sorted sets
+func SymDiffCount[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) (n int) {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	countOperation := func(a, b *T) {
+		if a == nil || b == nil {
+			n++
+		}
+	}
+	iterate(stream1, stream2, countOperation, unionStop, asc)
+	return
+}
+
+// Jaccard returns |A and B| / |A or B|, the similarity of two sets from 0 (disjoint) to 1 (equal)
+// Like the other coefficients it is computed in a single merge pass, it is 0 if both streams are empty
+func Jaccard[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) float64 {
+	nA, nB, interN := similarityCounts(stream1, stream2, asc)
+	return ratio(interN, nA+nB-interN)
+}
+
+// OverlapCoefficient returns |A and B| / min(|A|, |B|), so a subset is fully similar to its superset
+// It is 0 if either stream is empty
+func OverlapCoefficient[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) float64 {
+	nA, nB, interN := similarityCounts(stream1, stream2, asc)
+	if nB < nA {
+		nA = nB
+	}
+	return ratio(interN, nA)
+}
+
+// DiceCoefficient returns 2|A and B| / (|A| + |B|), it is 0 if both streams are empty
+func DiceCoefficient[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) float64 {
+	nA, nB, interN := similarityCounts(stream1, stream2, asc)
+	return ratio(2*interN, nA+nB)
+}
+
+// similarityCounts returns |A|, |B| and |A and B| counted in a single pass
+func similarityCounts[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) (nA, nB, interN int) {
+	_, interN, diffAN, diffBN := Estimate(stream1, stream2, asc)
+	return interN + diffAN, interN + diffBN, interN
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// Summary drains the stream and returns its smallest and largest items, the number of items and their sum,
+// a cheap profile of a merged result. Every value is zero for an empty stream.
+// Items are compared as they go, so the direction of the stream does not matter
+func Summary[T constraints.Integer | constraints.Float](stream SortedNumbersStream[T]) (min, max T, count int, sum T) {
+	stream = orEmpty(stream)
+	for {
+		item, ok := stream.Next()
+		if !ok {
+			return
+		}
+		if count == 0 || item < min {
+			min = item
+		}
+		if count == 0 || item > max {
+			max = item
+		}
+		count++
+		sum += item
+	}
+}
+
+// PairwiseIntersectCounts returns the matrix of |Ai and Aj| for every pair of streams (the diagonal holds |Ai|),
+// e.g. for co-occurrence analysis. Every factory is called once: all streams are read in a single K-way merge
+// and only the counts of the current value are kept, instead of intersecting N^2 pairs of fresh streams.
+// Repeated items are matched one to one like Intersect does
+func PairwiseIntersectCounts[T constraints.Ordered](factories []StreamFactory[T], asc bool) [][]int {
+	n := len(factories)
+	counts := make([][]int, n)
+	streams := make([]SortedNumbersStream[T], n)
+	for i, factory := range factories {
+		counts[i] = make([]int, n)
+		streams[i] = factory()
+	}
+	mustMatchDirection(asc, streams...)
+	merged := &mergeStream[T]{streams: streams, heads: &headsHeap[T]{asc: asc, cmp: compareOrdered[T]}}
+
+	var (
+		value       T
+		occurrences = make([]int, n) // of the value per stream
+		sources     []int            // streams containing the value
+	)
+	flush := func() {
+		for x, i := range sources {
+			for _, j := range sources[x:] {
+				matched := occurrences[i]
+				if occurrences[j] < matched {
+					matched = occurrences[j]
+				}
+				counts[i][j] += matched
+				if i != j {
+					counts[j][i] += matched
+				}
+			}
+		}
+		for _, i := range sources {
+			occurrences[i] = 0
+		}
+		sources = sources[:0]
+	}
+	for {
+		item, source, ok := merged.nextWithSource()
+		if !ok {
+			break
+		}
+		if len(sources) > 0 && compareOrdered(item, value) != 0 {
+			flush()
+		}
+		value = item
+		if occurrences[source] == 0 {
+			sources = append(sources, source)
+		}
+		occurrences[source]++
+	}
+	flush()
+	return counts
+}
@@ -0,0 +1,156 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingFixtures are operands to compare counting functions with the materialized operations
+var countingFixtures = []struct {
+	a, b []int
+	asc  bool
+}{
+	{[]int{}, []int{}, true},
+	{[]int{}, []int{1}, true},
+	{[]int{1}, []int{1}, true},
+	{[]int{1}, []int{0, 2}, true},
+	{[]int{0, 1, 2}, []int{1, 2, 3}, true},
+	{[]int{1, 2, 3}, []int{0, 1, 2}, true},
+	{[]int{}, []int{}, false},
+	{[]int{1}, []int{1, 0}, false},
+	{[]int{3, 2, 1}, []int{2, 1, 0}, false},
+	{[]int{2, 1, 0}, []int{1}, false},
+}
+
+func TestEstimate(t *testing.T) {
+	for i, tt := range countingFixtures {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			unionN, interN, diffAN, diffBN := Estimate[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)
+			require.Equal(t, len(ToSlice(Union[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))), unionN)
+			require.Equal(t, len(ToSlice(Intersect[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))), interN)
+			require.Equal(t, len(ToSlice(Diff[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))), diffAN)
+			require.Equal(t, len(ToSlice(Diff[int](NewSliceStream(tt.b), NewSliceStream(tt.a), tt.asc))), diffBN)
+		})
+	}
+}
+
+func TestDiffCount(t *testing.T) {
+	for i, tt := range countingFixtures {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			n := DiffCount[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)
+			require.Equal(t, len(ToSlice(Diff[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))), n)
+		})
+	}
+
+	// every occurrence of a repeated item found in B is removed
+	require.Equal(t, 3, DiffCount[int](NewSliceStream([]int{1, 1, 2, 2, 3}), NewSliceStream([]int{2}), true))
+}
+
+func TestSymDiffCount(t *testing.T) {
+	for i, tt := range countingFixtures {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := func(items []int) SortedNumbersStream[int] { return NewSliceStream(items) }
+			symDiff := Union[int](Diff[int](s(tt.a), s(tt.b), tt.asc), Diff[int](s(tt.b), s(tt.a), tt.asc), tt.asc)
+			require.Equal(t, len(ToSlice(symDiff)), SymDiffCount[int](s(tt.a), s(tt.b), tt.asc))
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	type test struct {
+		a, b                   []int
+		asc                    bool
+		jaccard, overlap, dice float64
+	}
+	tests := []test{
+		{[]int{}, []int{}, true, 0, 0, 0},
+		{[]int{}, []int{1}, true, 0, 0, 0},
+		{[]int{1, 2}, []int{1, 2}, true, 1, 1, 1},
+		{[]int{1, 2}, []int{3, 4}, true, 0, 0, 0},
+		{[]int{1, 2}, []int{1, 2, 3, 4}, true, 0.5, 1, 2.0 / 3},
+		{[]int{3, 2, 1}, []int{4, 3, 2}, false, 0.5, 2.0 / 3, 2.0 / 3},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := func(items []int) SortedNumbersStream[int] { return NewSliceStream(items) }
+			require.InDelta(t, tt.jaccard, Jaccard(s(tt.a), s(tt.b), tt.asc), 1e-9)
+			require.InDelta(t, tt.overlap, OverlapCoefficient(s(tt.a), s(tt.b), tt.asc), 1e-9)
+			require.InDelta(t, tt.dice, DiceCoefficient(s(tt.a), s(tt.b), tt.asc), 1e-9)
+		})
+	}
+}
+
+func TestSummary(t *testing.T) {
+	type test struct {
+		items                []int
+		min, max, count, sum int
+	}
+	tests := []test{
+		{[]int{}, 0, 0, 0, 0},
+		{[]int{5}, 5, 5, 1, 5},
+		{[]int{1, 2, 2, 5}, 1, 5, 4, 10},
+		{[]int{5, 2, -1}, -1, 5, 3, 6},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			min, max, count, sum := Summary[int](NewSliceStream(tt.items))
+			require.Equal(t, []int{tt.min, tt.max, tt.count, tt.sum}, []int{min, max, count, sum})
+		})
+	}
+
+	min, max, count, sum := Summary[float64](NewSliceStream([]float64{0.5, 1.5}))
+	require.Equal(t, []float64{0.5, 1.5, 2, 2}, []float64{min, max, float64(count), sum})
+}
+
+func TestPairwiseIntersectCounts(t *testing.T) {
+	sets := [][]int{
+		{1, 2, 3, 4},
+		{2, 4, 6},
+		{},
+		{1, 1, 2, 4},
+		{1, 1, 5},
+	}
+	factories := make([]StreamFactory[int], len(sets))
+	for i, set := range sets {
+		factories[i] = SliceFactory(set)
+	}
+
+	counts := PairwiseIntersectCounts(factories, true)
+	for i := range sets {
+		for j := range sets {
+			expected := len(ToSlice(Intersect[int](factories[i](), factories[j](), true)))
+			require.Equal(t, expected, counts[i][j], fmt.Sprintf("%d and %d", i, j))
+		}
+	}
+
+	require.EqualValues(t, [][]int{}, PairwiseIntersectCounts[int](nil, true))
+}
+
+// BenchmarkPairwiseIntersectCounts compares the single merge with intersecting every pair of streams
+func BenchmarkPairwiseIntersectCounts(b *testing.B) {
+	sets := randomShards(rand.New(rand.NewSource(1)), 50, 1000)
+	factories := make([]StreamFactory[int], len(sets))
+	for i, set := range sets {
+		factories[i] = SliceFactory(set)
+	}
+
+	b.Run("merge", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			PairwiseIntersectCounts(factories, true)
+		}
+	})
+	b.Run("pairs", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			for i := range factories {
+				for j := range factories {
+					ToSlice(Intersect[int](factories[i](), factories[j](), true, WithPullBackend()))
+				}
+			}
+		}
+	})
+}
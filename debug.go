@@ -0,0 +1,232 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/constraints"
+)
+
+// OperationMetrics shows the algorithmic cost of an operation
+type OperationMetrics struct {
+	NextA, NextB int // Next() calls on operands, including the final one that found the stream drained
+	Comparisons  int // comparisons of operand items
+	Emitted      int // items in the result
+}
+
+// UnionDebug is Union which also counts reads and comparisons
+// It runs synchronously and materializes the result, so it is meant for profiling and teaching, not the hot path
+func UnionDebug[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) ([]T, OperationMetrics) {
+	return runDebug(stream1, stream2, unionPick[T], unionStop, asc)
+}
+
+// IntersectDebug is Intersect which also counts reads and comparisons (see UnionDebug)
+func IntersectDebug[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) ([]T, OperationMetrics) {
+	return runDebug(stream1, stream2, intersectPick[T], intersectStop, asc)
+}
+
+// DiffDebug is Diff which also counts reads and comparisons (see UnionDebug)
+func DiffDebug[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) ([]T, OperationMetrics) {
+	return runDebug(stream1, stream2, newDiffPick[T](), diffStop, asc)
+}
+
+func runDebug[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], pick picker[T], stop shouldStop, asc bool) ([]T, OperationMetrics) {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+
+	var metrics OperationMetrics
+	result := make([]T, 0)
+	countingCompare := func(a, b T) int {
+		metrics.Comparisons++
+		return compareOrdered(a, b)
+	}
+	m := newMerger[T](
+		&nextCounter[T]{stream1, &metrics.NextA},
+		&nextCounter[T]{stream2, &metrics.NextB},
+		countingCompare,
+		stop,
+		asc,
+	)
+	m.run(func(a, b *T) {
+		if item := pick(a, b); item != nil {
+			result = append(result, *item)
+		}
+	})
+	metrics.Emitted = len(result)
+
+	return result, metrics
+}
+
+// nextCounter counts Next calls of the wrapped stream
+type nextCounter[T any] struct {
+	SortedNumbersStream[T]
+	calls *int
+}
+
+func (s *nextCounter[T]) Next() (T, bool) {
+	*s.calls++
+	return s.SortedNumbersStream.Next()
+}
+
+// Record wraps the stream to remember every item read from it, the returned func gives the read history
+// so wrong results of an operation can be traced to what exactly each operand emitted.
+// The history is safe to get while the stream is still being read (e.g. by an operation goroutine)
+func Record[T any](stream SortedNumbersStream[T]) (SortedNumbersStream[T], func() []T) {
+	stream = orEmpty(stream)
+	r := &recorder[T]{stream: stream}
+	history := func() []T {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return append([]T{}, r.history...)
+	}
+	if d, ok := stream.(DirectedStream[T]); ok {
+		return &directedStream[T]{r, d.Asc()}, history // keep the direction visible to operations
+	}
+	return r, history
+}
+
+type recorder[T any] struct {
+	stream  SortedNumbersStream[T]
+	mu      sync.Mutex
+	history []T
+}
+
+func (r *recorder[T]) Next() (item T, ok bool) {
+	if item, ok = r.stream.Next(); ok {
+		r.mu.Lock()
+		r.history = append(r.history, item)
+		r.mu.Unlock()
+	}
+	return
+}
+
+// StreamMetrics are counters of a metered stream, safe to read while the stream is being read
+type StreamMetrics interface {
+	// Name identifies the stream, e.g. as a metric label
+	Name() string
+	// Read returns the number of items read so far
+	Read() uint64
+	// Blocked returns the total time spent in Next of the wrapped stream (waiting for I/O or an operation goroutine)
+	Blocked() time.Duration
+}
+
+// MeteredStream counts items read from the wrapped stream and the time spent waiting for them.
+// Counters are raw numbers, so they can be exported with any metrics library, e.g. with expvar:
+//
+//	postings := NewMeteredStream(stream, "postings")
+//	expvar.Publish("postings_read", expvar.Func(func() any { return postings.Read() }))
+//
+// or scraped by a Prometheus CounterFunc the same way.
+// Len and batches of the wrapped stream are forwarded, its direction is not: the meter can't tell a stream
+// without one, so mark the meter of a directed stream with WithDirection to keep the direction checks
+type MeteredStream[T any] struct {
+	stream  SortedNumbersStream[T]
+	name    string
+	read    atomic.Uint64
+	blocked atomic.Int64
+}
+
+func (s *MeteredStream[T]) Next() (item T, ok bool) {
+	start := time.Now()
+	item, ok = s.stream.Next()
+	s.blocked.Add(int64(time.Since(start)))
+	if ok {
+		s.read.Add(1)
+	}
+	return
+}
+
+// NextN forwards batches of a BatchNext stream, other streams are read item by item
+func (s *MeteredStream[T]) NextN(buf []T) (n int, ok bool) {
+	b, batched := s.stream.(BatchNext[T])
+	if !batched {
+		for n < len(buf) {
+			if buf[n], ok = s.Next(); !ok {
+				break
+			}
+			n++
+		}
+		return n, n > 0 || len(buf) == 0
+	}
+	start := time.Now()
+	n, ok = b.NextN(buf)
+	s.blocked.Add(int64(time.Since(start)))
+	s.read.Add(uint64(n))
+	return n, ok
+}
+
+func (s *MeteredStream[T]) Len() (int, bool) { return sizeOf(s.stream) }
+
+// Err forwards the error of an Errorable stream
+func (s *MeteredStream[T]) Err() error {
+	if e, ok := s.stream.(Errorable); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+func (s *MeteredStream[T]) Name() string           { return s.name }
+func (s *MeteredStream[T]) Read() uint64           { return s.read.Load() }
+func (s *MeteredStream[T]) Blocked() time.Duration { return time.Duration(s.blocked.Load()) }
+
+// NewMeteredStream wraps the stream to collect StreamMetrics under the given name
+func NewMeteredStream[T any](stream SortedNumbersStream[T], name string) *MeteredStream[T] {
+	return &MeteredStream[T]{stream: orEmpty(stream), name: name}
+}
+
+// CheckDirections enables CheckDirection, turn it on in tests or debug builds with CheckDirections.Store(true).
+// It is read when a stream is marked, so it is safe to toggle while other goroutines mark streams
+var CheckDirections atomic.Bool
+
+// directionCheckItems is the number of leading items CheckDirection verifies
+const directionCheckItems = 64
+
+// CheckDirection marks the stream with its declared direction, like WithDirection.
+// With CheckDirections enabled it also verifies that the first items are ordered accordingly and panics otherwise,
+// catching a wrong asc flag that would make operations silently return wrong results
+func CheckDirection[T constraints.Ordered](stream SortedNumbersStream[T], asc bool) DirectedStream[T] {
+	stream = orEmpty(stream)
+	if !CheckDirections.Load() {
+		return &directedStream[T]{stream, asc}
+	}
+	return &directedStream[T]{&directionChecker[T]{stream: stream, asc: asc}, asc}
+}
+
+type directionChecker[T constraints.Ordered] struct {
+	stream SortedNumbersStream[T]
+	asc    bool
+	read   int
+	prev   T
+}
+
+func (s *directionChecker[T]) Next() (item T, ok bool) {
+	item, ok = s.stream.Next()
+	if !ok || s.read >= directionCheckItems {
+		return
+	}
+	if s.read > 0 && goesBefore(item, s.prev, s.asc) {
+		panic(fmt.Sprintf("stream is declared asc=%t, but item %d (%v) goes after %v", s.asc, s.read, item, s.prev))
+	}
+	s.read++
+	s.prev = item
+	return
+}
+
+// ValidateSorted reads the stream to find where it breaks the order (repeated items are fine): index is the position
+// of the first item cur going before its predecessor prev. Reading stops there, so the rest of the stream is not read.
+// A sorted stream gives ok=true and index=-1 once it is drained. Unlike CheckDirection, it is meant for inspecting
+// suspicious data, e.g. a broken index file
+func ValidateSorted[T constraints.Ordered](stream SortedNumbersStream[T], asc bool) (ok bool, index int, prev, cur T) {
+	stream = orEmpty(stream)
+	prev, has := stream.Next()
+	for index = 1; has; index++ {
+		if cur, has = stream.Next(); has && goesBefore(cur, prev, asc) {
+			return false, index, prev, cur
+		}
+		prev = cur
+	}
+	var zero T
+	return true, -1, zero, zero
+}
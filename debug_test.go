@@ -0,0 +1,166 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugMetrics(t *testing.T) {
+	// overlapping: every position needs a comparison
+	result, metrics := IntersectDebug[int](NewSliceStream([]int{1, 2, 3}), NewSliceStream([]int{1, 2, 3}), true)
+	require.EqualValues(t, []int{1, 2, 3}, result)
+	require.Equal(t, OperationMetrics{NextA: 4, NextB: 3, Comparisons: 3, Emitted: 3}, metrics)
+
+	// disjoint ranges: intersect stops as soon as one operand is drained
+	result, metrics = IntersectDebug[int](NewSliceStream([]int{1, 2, 3}), NewSliceStream([]int{10, 11, 12}), true)
+	require.EqualValues(t, []int{}, result)
+	require.Equal(t, OperationMetrics{NextA: 4, NextB: 1, Comparisons: 3, Emitted: 0}, metrics)
+
+	// union reads everything
+	result, metrics = UnionDebug[int](NewSliceStream([]int{1, 2, 3}), NewSliceStream([]int{10, 11, 12}), true)
+	require.EqualValues(t, []int{1, 2, 3, 10, 11, 12}, result)
+	require.Equal(t, OperationMetrics{NextA: 4, NextB: 4, Comparisons: 3, Emitted: 6}, metrics)
+
+	result, metrics = DiffDebug[int](NewSliceStream([]int{3, 2, 1}), NewSliceStream([]int{2}), false)
+	require.EqualValues(t, []int{3, 1}, result)
+	require.Equal(t, 2, metrics.Comparisons)
+}
+
+func TestRecord(t *testing.T) {
+	a, historyA := Record[int](NewSliceStream([]int{1, 2, 3}))
+	b, historyB := Record[int](NewSliceStream([]int{1}))
+
+	result := Intersect[int](a, b, true)
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.EqualValues(t, []int{1, 2}, historyA()) // "2" was read before intersect stopped
+	require.EqualValues(t, []int{1}, historyB())
+
+	directed, _ := Record[int](WithDirection[int](NewSliceStream([]int{}), false))
+	require.False(t, directed.(DirectedStream[int]).Asc())
+
+	empty, history := Record[int](nil)
+	require.EqualValues(t, []int{}, ToSlice(empty))
+	require.Len(t, history(), 0)
+}
+
+// slowStream delays every item
+type slowStream struct {
+	SortedNumbersStream[int]
+	delay time.Duration
+}
+
+func (s *slowStream) Next() (int, bool) {
+	time.Sleep(s.delay)
+	return s.SortedNumbersStream.Next()
+}
+
+func TestMeteredStream(t *testing.T) {
+	stream := NewMeteredStream[int](&slowStream{NewSliceStream([]int{1, 2, 3}), time.Millisecond}, "postings")
+	var metrics StreamMetrics = stream
+
+	result := Intersect[int](stream, NewSliceStream([]int{2, 3}), true)
+	require.EqualValues(t, []int{2, 3}, ToSlice(result))
+
+	require.Equal(t, "postings", metrics.Name())
+	require.EqualValues(t, 3, metrics.Read())
+	require.True(t, metrics.Blocked() >= 3*time.Millisecond)
+}
+
+func TestMeteredStreamForwardsFastPaths(t *testing.T) {
+	stream := NewMeteredStream[int](NewSliceStream([]int{1, 2, 3}), "ids")
+	n, known := stream.Len()
+	require.True(t, known)
+	require.Equal(t, 3, n)
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice[int](stream)) // read in batches
+	require.EqualValues(t, 3, stream.Read())
+
+	failure := errors.New("disk failure")
+	failing := NewMeteredStream[int](&failingStream{[]int{1}, failure}, "failing")
+	require.EqualValues(t, []int{1}, ToSlice[int](failing))
+	require.ErrorIs(t, failing.Err(), failure)
+
+	desc := WithDirection[int](NewMeteredStream[int](NewSliceStream([]int{3, 1}), "desc"), false)
+	require.Panics(t, func() { Union[int](desc, NewSliceStream([]int{2}), true) })
+}
+
+func TestCheckDirection(t *testing.T) {
+	CheckDirections.Store(true)
+	defer CheckDirections.Store(false)
+
+	require.EqualValues(t, []int{1, 2, 2, 3}, ToSlice[int](CheckDirection[int](NewSliceStream([]int{1, 2, 2, 3}), true)))
+	require.EqualValues(t, []int{3, 1}, ToSlice[int](CheckDirection[int](NewSliceStream([]int{3, 1}), false)))
+
+	desc := CheckDirection[int](NewSliceStream([]int{3, 2, 1}), true)
+	require.Panics(t, func() { ToSlice[int](desc) })
+
+	asc := CheckDirection[int](NewSliceStream([]int{1, 2}), false)
+	require.Panics(t, func() { ToSlice(Union[int](asc, NewSliceStream([]int{3}), false, WithPullBackend())) })
+}
+
+func TestCheckDirectionNaN(t *testing.T) {
+	CheckDirections.Store(true)
+	defer CheckDirections.Store(false)
+
+	items := ToSlice[float64](CheckDirection[float64](NewSliceStream([]float64{math.NaN(), 1, 2}), true))
+	require.Len(t, items, 3)
+	late := CheckDirection[float64](NewSliceStream([]float64{1, math.NaN()}), true) // NaN goes first
+	require.Panics(t, func() { ToSlice[float64](late) })
+}
+
+func TestCheckDirectionDisabled(t *testing.T) {
+	s := CheckDirection[int](NewSliceStream([]int{3, 2, 1}), true)
+	require.True(t, s.Asc())
+	require.EqualValues(t, []int{3, 2, 1}, ToSlice[int](s)) // not verified
+}
+
+func TestValidateSorted(t *testing.T) {
+	type test struct {
+		items     []int
+		asc       bool
+		ok        bool
+		index     int
+		prev, cur int
+	}
+	tests := []test{
+		{[]int{}, true, true, -1, 0, 0},
+		{[]int{5}, true, true, -1, 0, 0},
+		{[]int{1, 2, 2, 3}, true, true, -1, 0, 0},
+		{[]int{3, 2, 2, 1}, false, true, -1, 0, 0},
+		{[]int{1, 3, 2, 4}, true, false, 2, 3, 2},
+		{[]int{2, 1}, true, false, 1, 2, 1},
+		{[]int{3, 2, 4, 1}, false, false, 2, 2, 4},
+		{[]int{1, 2, 3}, false, false, 1, 1, 2},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			ok, index, prev, cur := ValidateSorted[int](NewSliceStream(tt.items), tt.asc)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.index, index)
+			require.Equal(t, tt.prev, prev)
+			require.Equal(t, tt.cur, cur)
+		})
+	}
+}
+
+func TestValidateSortedNaN(t *testing.T) {
+	ok, _, _, _ := ValidateSorted[float64](NewSliceStream([]float64{math.NaN(), math.NaN(), 1}), true)
+	require.True(t, ok)
+	ok, index, prev, cur := ValidateSorted[float64](NewSliceStream([]float64{1, math.NaN()}), true)
+	require.False(t, ok)
+	require.Equal(t, 1, index)
+	require.Equal(t, 1.0, prev)
+	require.True(t, math.IsNaN(cur))
+}
+
+func TestValidateSortedStopsAtViolation(t *testing.T) {
+	stream, history := Record[int](NewSliceStream([]int{1, 5, 4, 6, 7}))
+	ok, index, _, _ := ValidateSorted(stream, true)
+	require.False(t, ok)
+	require.Equal(t, 2, index)
+	require.Len(t, history(), 3)
+}
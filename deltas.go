@@ -0,0 +1,280 @@
+package sorted_numeric_streams
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ErrOverflow is reported by Deltas and CumulativeSum when a result does not fit T (see WithOverflow)
+var ErrOverflow = errors.New("integer overflow")
+
+// OverflowPolicy tells what Deltas and CumulativeSum do with an integer result which does not fit T
+type OverflowPolicy int
+
+const (
+	// ErrorOnOverflow stops the stream before the result, its Err reports ErrOverflow
+	ErrorOnOverflow OverflowPolicy = iota
+	// WrapAround emits the result modulo the size of T, like Go arithmetic does
+	WrapAround
+	// Saturate emits the largest or the smallest value of T instead
+	Saturate
+)
+
+// TransformOption configures Deltas and CumulativeSum
+type TransformOption func(*OverflowPolicy)
+
+// WithOverflow sets how overflows are treated, ErrorOnOverflow by default
+func WithOverflow(policy OverflowPolicy) TransformOption {
+	return func(p *OverflowPolicy) { *p = policy }
+}
+
+// overflowPolicy applies the options
+func overflowPolicy(opts []TransformOption) (policy OverflowPolicy) {
+	for _, opt := range opts {
+		opt(&policy)
+	}
+	return policy
+}
+
+// Deltas emits the difference between every item and its predecessor, e.g. [1,3,7] gives [1,2,4] with keepFirst
+// and [2,4] without it, to compress a merged posting list on the fly.
+// The result is not sorted, so it is not meant to be an operand of set operations.
+// Deltas of a desc stream are negative, so unsigned types overflow (see WithOverflow, WrapAround keeps them reversible)
+func Deltas[T constraints.Integer](stream SortedNumbersStream[T], keepFirst bool, opts ...TransformOption) SortedNumbersStream[T] {
+	return &deltasStream[T]{stream: orEmpty(stream), keepFirst: keepFirst, overflow: overflowPolicy(opts)}
+}
+
+type deltasStream[T constraints.Integer] struct {
+	stream    SortedNumbersStream[T]
+	keepFirst bool
+	overflow  OverflowPolicy
+	prev      T
+	started   bool
+	err       error
+}
+
+func (s *deltasStream[T]) Next() (delta T, ok bool) {
+	if s.err != nil {
+		return delta, false
+	}
+	item, ok := s.stream.Next()
+	if ok && !s.started {
+		s.prev, s.started = item, true
+		if !s.keepFirst {
+			return s.Next()
+		}
+		return item, true
+	}
+	if !ok {
+		return delta, false
+	}
+	delta = item - s.prev
+	if s.prev > 0 && delta > item || s.prev < 0 && delta < item {
+		switch s.overflow {
+		case ErrorOnOverflow:
+			s.err = fmt.Errorf("%w: %v - %v", ErrOverflow, item, s.prev)
+			return 0, false
+		case Saturate:
+			delta = saturated[T](s.prev < 0)
+		}
+	}
+	s.prev = item
+	return delta, true
+}
+
+// Err returns ErrOverflow if a delta did not fit T with the ErrorOnOverflow policy
+func (s *deltasStream[T]) Err() error { return s.err }
+
+// CumulativeSum emits the running total of the stream, e.g. [1,2,3] gives [1,3,6]
+// With Deltas(stream, true) as the input it restores the original stream.
+// Integer totals may overflow (see WithOverflow), float totals go to infinity instead
+func CumulativeSum[T constraints.Integer | constraints.Float](stream SortedNumbersStream[T], opts ...TransformOption) SortedNumbersStream[T] {
+	var half T = 1
+	half /= 2
+	return &cumulativeSumStream[T]{stream: orEmpty(stream), overflow: overflowPolicy(opts), integer: half == 0}
+}
+
+type cumulativeSumStream[T constraints.Integer | constraints.Float] struct {
+	stream   SortedNumbersStream[T]
+	overflow OverflowPolicy
+	integer  bool // float totals are not checked
+	total    T
+	err      error
+}
+
+func (s *cumulativeSumStream[T]) Next() (total T, ok bool) {
+	if s.err != nil {
+		return total, false
+	}
+	item, ok := s.stream.Next()
+	if !ok {
+		return total, false
+	}
+	total = s.total + item
+	if s.integer && (item > 0 && total < s.total || item < 0 && total > s.total) {
+		switch s.overflow {
+		case ErrorOnOverflow:
+			s.err = fmt.Errorf("%w: %v + %v", ErrOverflow, s.total, item)
+			return 0, false
+		case Saturate:
+			total = saturated[T](item > 0)
+		}
+	}
+	s.total = total
+	return total, true
+}
+
+// Err returns ErrOverflow if the total did not fit T with the ErrorOnOverflow policy
+func (s *cumulativeSumStream[T]) Err() error { return s.err }
+
+// saturated returns the largest or the smallest value of the integer type T
+func saturated[T constraints.Integer | constraints.Float](largest bool) T {
+	// the largest power of two is found by doubling until it overflows, the largest value is twice that minus one
+	var power T = 1
+	for power*2 > power {
+		power *= 2
+	}
+	if largest {
+		return power + (power - 1)
+	}
+	var zero T
+	if zero-1 > zero { // unsigned
+		return 0
+	}
+	return -power - power
+}
+
+// WindowAggregate emits agg of every sliding window of windowSize consecutive items, e.g. a sum over windows of 3
+// turns [1,2,3,4] into [6,9], to smooth a merged time series. A stream shorter than the window gives no items.
+// Only the window is buffered, agg gets the items in stream order and must not keep the slice
+func WindowAggregate[T constraints.Integer | constraints.Float](stream SortedNumbersStream[T], windowSize int, agg func([]T) T) SortedNumbersStream[T] {
+	if windowSize < 1 {
+		panic("window size must be positive")
+	}
+	return &windowStream[T]{stream: orEmpty(stream), window: make([]T, 0, windowSize), agg: agg}
+}
+
+type windowStream[T constraints.Integer | constraints.Float] struct {
+	stream SortedNumbersStream[T]
+	window []T
+	agg    func([]T) T
+}
+
+func (s *windowStream[T]) Next() (aggregate T, ok bool) {
+	if len(s.window) == cap(s.window) { // slide
+		copy(s.window, s.window[1:])
+		s.window = s.window[:len(s.window)-1]
+	}
+	for len(s.window) < cap(s.window) {
+		item, ok := s.stream.Next()
+		if !ok {
+			return aggregate, false
+		}
+		s.window = append(s.window, item)
+	}
+	return s.agg(s.window), true
+}
+
+// MovingMedian emits the median of every sliding window of window consecutive items (see WindowAggregate),
+// the mean of the two middle items for an even window (truncated for integers). The items do not have to be sorted.
+// The window is kept in two heaps (the smaller half and the larger half), so every step costs O(log(window))
+// and memory is O(window). NaN items are not supported
+func MovingMedian[T constraints.Integer | constraints.Float](stream SortedNumbersStream[T], window int) SortedNumbersStream[T] {
+	if window < 1 {
+		panic("window size must be positive")
+	}
+	return &medianStream[T]{
+		stream:  orEmpty(stream),
+		window:  make([]T, 0, window),
+		low:     &orderedHeap[T]{asc: false},
+		high:    &orderedHeap[T]{asc: true},
+		delayed: make(map[T]int),
+	}
+}
+
+type medianStream[T constraints.Integer | constraints.Float] struct {
+	stream SortedNumbersStream[T]
+	window []T // ring of the window items, to know which one leaves
+	oldest int
+	filled bool
+	// low keeps the smaller half of the window (the top is the largest), high keeps the larger half.
+	// Items leaving the window are removed lazily: they are counted in delayed until they reach a top
+	low, high         *orderedHeap[T]
+	lowSize, highSize int // items of the window in each heap
+	delayed           map[T]int
+}
+
+func (s *medianStream[T]) Next() (median T, ok bool) {
+	if s.filled { // slide the window
+		item, ok := s.stream.Next()
+		if !ok {
+			return median, false
+		}
+		s.remove(s.window[s.oldest])
+		s.window[s.oldest] = item
+		s.oldest = (s.oldest + 1) % cap(s.window)
+		s.add(item)
+	}
+	for len(s.window) < cap(s.window) {
+		item, ok := s.stream.Next()
+		if !ok {
+			return median, false
+		}
+		s.window = append(s.window, item)
+		s.add(item)
+	}
+	s.filled = true
+	if cap(s.window)%2 == 1 {
+		return s.low.items[0], true
+	}
+	return (s.low.items[0] + s.high.items[0]) / 2, true
+}
+
+func (s *medianStream[T]) add(item T) {
+	if s.low.Len() == 0 || item <= s.low.items[0] {
+		heap.Push(s.low, item)
+		s.lowSize++
+	} else {
+		heap.Push(s.high, item)
+		s.highSize++
+	}
+	s.balance()
+}
+
+func (s *medianStream[T]) remove(item T) {
+	s.delayed[item]++
+	if item <= s.low.items[0] {
+		s.lowSize--
+		s.prune(s.low)
+	} else {
+		s.highSize--
+		s.prune(s.high)
+	}
+	s.balance()
+}
+
+// balance keeps low equal to high or larger by one item
+func (s *medianStream[T]) balance() {
+	if s.lowSize > s.highSize+1 {
+		heap.Push(s.high, heap.Pop(s.low))
+		s.lowSize, s.highSize = s.lowSize-1, s.highSize+1
+		s.prune(s.low)
+	} else if s.lowSize < s.highSize {
+		heap.Push(s.low, heap.Pop(s.high))
+		s.lowSize, s.highSize = s.lowSize+1, s.highSize-1
+		s.prune(s.high)
+	}
+}
+
+// prune pops removed items off the top of the heap
+func (s *medianStream[T]) prune(h *orderedHeap[T]) {
+	for h.Len() > 0 && s.delayed[h.items[0]] > 0 {
+		if s.delayed[h.items[0]]--; s.delayed[h.items[0]] == 0 {
+			delete(s.delayed, h.items[0])
+		}
+		heap.Pop(h)
+	}
+}
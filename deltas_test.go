@@ -0,0 +1,171 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeltas(t *testing.T) {
+	type test struct {
+		items     []int
+		keepFirst bool
+		result    []int
+	}
+	tests := []test{
+		{[]int{1, 3, 7}, true, []int{1, 2, 4}},
+		{[]int{1, 3, 7}, false, []int{2, 4}},
+		{[]int{5}, true, []int{5}},
+		{[]int{5}, false, []int{}},
+		{[]int{}, true, []int{}},
+		{[]int{7, 3, 1}, true, []int{7, -4, -2}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(Deltas[int](NewSliceStream(tt.items), tt.keepFirst)))
+		})
+	}
+}
+
+func TestDeltasOfUnion(t *testing.T) {
+	union := Union[uint32](NewSliceStream([]uint32{10, 20, 30}), NewSliceStream([]uint32{15, 30, 31}), true)
+	require.EqualValues(t, []uint32{10, 5, 5, 10, 1}, ToSlice(Deltas[uint32](union, true)))
+}
+
+func TestCumulativeSum(t *testing.T) {
+	require.EqualValues(t, []int{1, 3, 6}, ToSlice(CumulativeSum[int](NewSliceStream([]int{1, 2, 3}))))
+	require.EqualValues(t, []int{}, ToSlice(CumulativeSum[int](NewSliceStream([]int{}))))
+	require.EqualValues(t, []float64{0.5, 2}, ToSlice(CumulativeSum[float64](NewSliceStream([]float64{0.5, 1.5}))))
+
+	items := []int{3, 4, 10, 11}
+	require.EqualValues(t, items, ToSlice(CumulativeSum(Deltas[int](NewSliceStream(items), true))))
+}
+
+func TestOverflowPolicies(t *testing.T) {
+	type test struct {
+		name   string
+		stream func(opts ...TransformOption) SortedNumbersStream[int]
+		policy OverflowPolicy
+		result []int
+		failed bool
+	}
+	sum := func(items ...int) func(opts ...TransformOption) SortedNumbersStream[int] {
+		return func(opts ...TransformOption) SortedNumbersStream[int] {
+			return CumulativeSum[int](NewSliceStream(items), opts...)
+		}
+	}
+	deltas := func(items ...int) func(opts ...TransformOption) SortedNumbersStream[int] {
+		return func(opts ...TransformOption) SortedNumbersStream[int] {
+			return Deltas[int](NewSliceStream(items), true, opts...)
+		}
+	}
+	tests := []test{
+		{"sum error", sum(math.MaxInt-1, 1, 1), ErrorOnOverflow, []int{math.MaxInt - 1, math.MaxInt}, true},
+		{"sum wrap", sum(math.MaxInt-1, 1, 1), WrapAround, []int{math.MaxInt - 1, math.MaxInt, math.MinInt}, false},
+		{"sum saturate", sum(math.MaxInt-1, 1, 1, -5), Saturate, []int{math.MaxInt - 1, math.MaxInt, math.MaxInt, math.MaxInt - 5}, false},
+		{"sum saturate down", sum(math.MinInt+1, -2), Saturate, []int{math.MinInt + 1, math.MinInt}, false},
+		{"sum fits", sum(math.MaxInt, math.MinInt), ErrorOnOverflow, []int{math.MaxInt, -1}, false},
+		{"deltas error", deltas(math.MinInt, math.MaxInt), ErrorOnOverflow, []int{math.MinInt}, true},
+		{"deltas wrap", deltas(math.MinInt, math.MaxInt), WrapAround, []int{math.MinInt, -1}, false},
+		{"deltas saturate", deltas(math.MinInt, math.MaxInt), Saturate, []int{math.MinInt, math.MaxInt}, false},
+		{"deltas saturate down", deltas(math.MaxInt, -2), Saturate, []int{math.MaxInt, math.MinInt}, false},
+		{"deltas fit", deltas(-1, math.MaxInt-1), ErrorOnOverflow, []int{-1, math.MaxInt}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []TransformOption{WithOverflow(tt.policy)}
+			if tt.policy == ErrorOnOverflow {
+				opts = nil // the default
+			}
+			s := tt.stream(opts...)
+			require.EqualValues(t, tt.result, ToSlice(s))
+			err := s.(Errorable).Err()
+			require.Equal(t, tt.failed, errors.Is(err, ErrOverflow))
+			if !tt.failed {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOverflowOfSmallTypes(t *testing.T) {
+	desc := []uint8{200, 100, 50}
+	require.EqualValues(t, []uint8{200}, ToSlice(Deltas[uint8](NewSliceStream(desc), true)))
+	wrapped := ToSlice(Deltas[uint8](NewSliceStream(desc), true, WithOverflow(WrapAround)))
+	require.EqualValues(t, []uint8{200, 156, 206}, wrapped)
+	require.EqualValues(t, desc, ToSlice(CumulativeSum[uint8](NewSliceStream(wrapped), WithOverflow(WrapAround))))
+	require.EqualValues(t, []uint8{200, 0, 0}, ToSlice(Deltas[uint8](NewSliceStream(desc), true, WithOverflow(Saturate))))
+
+	require.EqualValues(t, []int8{100, 127, 27}, ToSlice(CumulativeSum[int8](NewSliceStream([]int8{100, 100, -100}), WithOverflow(Saturate))))
+	require.EqualValues(t, []int8{-100, -128}, ToSlice(CumulativeSum[int8](NewSliceStream([]int8{-100, -100}), WithOverflow(Saturate))))
+	require.EqualValues(t, []float64{1e20, 1e20}, ToSlice(CumulativeSum[float64](NewSliceStream([]float64{1e20, 1}))))
+}
+
+func TestWindowAggregate(t *testing.T) {
+	sum := func(items []int) (total int) {
+		for _, item := range items {
+			total += item
+		}
+		return
+	}
+	type test struct {
+		items  []int
+		window int
+		result []int
+	}
+	tests := []test{
+		{[]int{}, 3, []int{}},
+		{[]int{1, 2}, 3, []int{}},
+		{[]int{1, 2, 3}, 3, []int{6}},
+		{[]int{1, 2, 3, 4, 10}, 3, []int{6, 9, 17}},
+		{[]int{1, 2, 3}, 1, []int{1, 2, 3}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(WindowAggregate[int](NewSliceStream(tt.items), tt.window, sum)))
+		})
+	}
+
+	require.Panics(t, func() { WindowAggregate[int](NewSliceStream([]int{1}), 0, sum) })
+}
+
+func TestWindowAggregateMax(t *testing.T) {
+	maxOf := func(items []float64) float64 { return items[len(items)-1] } // the last item of an asc window
+	require.EqualValues(t, []float64{2, 3.5}, ToSlice(WindowAggregate[float64](NewSliceStream([]float64{1, 2, 3.5}), 2, maxOf)))
+}
+
+func TestMovingMedian(t *testing.T) {
+	require.EqualValues(t, []int{2, 3, 4}, ToSlice(MovingMedian[int](NewSliceStream([]int{1, 2, 3, 4, 5}), 3)))
+	require.EqualValues(t, []float64{1.5, 2.5}, ToSlice(MovingMedian[float64](NewSliceStream([]float64{1, 2, 3}), 2)))
+	require.EqualValues(t, []int{}, ToSlice(MovingMedian[int](NewSliceStream([]int{1, 2}), 3)))
+	require.Panics(t, func() { MovingMedian[int](nil, 0) })
+}
+
+func TestMovingMedianMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, window := range []int{1, 2, 3, 4, 7, 16} {
+		items := make([]int, 300)
+		for i := range items {
+			items[i] = r.Intn(20) // many repeated items
+		}
+
+		var expected []int
+		for i := 0; i+window <= len(items); i++ {
+			sorted := append([]int{}, items[i:i+window]...)
+			sort.Ints(sorted)
+			median := sorted[window/2]
+			if window%2 == 0 {
+				median = (sorted[window/2-1] + sorted[window/2]) / 2
+			}
+			expected = append(expected, median)
+		}
+		require.EqualValues(t, expected, ToSlice(MovingMedian[int](NewSliceStream(items), window)), fmt.Sprintf("window %d", window))
+	}
+}
@@ -0,0 +1,107 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// Normalize returns the stream sorted in the wanted direction, so differently ordered sources can be combined.
+// The direction of a DirectedStream is known, otherwise it is detected by reading items until two distinct ones show up.
+// Only a stream in the opposite direction is reversed, which materializes it in memory.
+func Normalize[T constraints.Ordered](stream SortedNumbersStream[T], wantAsc bool) DirectedStream[T] {
+	stream = orEmpty(stream)
+	if d, ok := stream.(DirectedStream[T]); ok {
+		if d.Asc() == wantAsc {
+			return d
+		}
+		return &directedStream[T]{reverse(stream), wantAsc}
+	}
+
+	asc, known, stream := detectDirection(stream)
+	if known && asc != wantAsc {
+		return &directedStream[T]{reverse(stream), wantAsc}
+	}
+	return &directedStream[T]{stream, wantAsc}
+}
+
+// detectDirection reads the stream until two distinct items show up
+// known is false when the stream has no distinct items (then any direction fits)
+// the returned stream replays the items read for detection
+func detectDirection[T constraints.Ordered](stream SortedNumbersStream[T]) (asc, known bool, replay SortedNumbersStream[T]) {
+	var prefix []T
+	for {
+		item, ok := stream.Next()
+		if !ok {
+			break
+		}
+		prefix = append(prefix, item)
+		if c := compareOrdered(prefix[0], item); c != 0 {
+			asc, known = c < 0, true
+			break
+		}
+	}
+	pushback := NewPushback(stream)
+	for i := len(prefix) - 1; i >= 0; i-- {
+		pushback.Unread(prefix[i])
+	}
+	return asc, known, pushback
+}
+
+// reverse materializes the stream in reverse order
+func reverse[T constraints.Ordered](stream SortedNumbersStream[T]) *SliceStream[T] {
+	items := AppendTo(nil, stream) // a copy, so the source of a SliceStream is not modified
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return NewSliceStream(items)
+}
+
+// Pushback is a stream which items can be put back after reading them, e.g. heads read to choose an algorithm
+// or to detect the direction, so an operation started afterwards does not lose them
+type Pushback[T any] struct {
+	stream SortedNumbersStream[T]
+	unread []T // the last item goes first
+}
+
+// NewPushback wraps the stream to allow Unread
+func NewPushback[T any](stream SortedNumbersStream[T]) *Pushback[T] {
+	return &Pushback[T]{stream: orEmpty(stream)}
+}
+
+func (s *Pushback[T]) Next() (item T, ok bool) {
+	if n := len(s.unread); n > 0 {
+		item, s.unread = s.unread[n-1], s.unread[:n-1]
+		return item, true
+	}
+	return s.stream.Next()
+}
+
+// Unread puts the item back, so Next returns it again. Several items are returned in the reverse order of Unread calls,
+// so unread them from the last read to keep the stream sorted
+func (s *Pushback[T]) Unread(item T) { s.unread = append(s.unread, item) }
+
+// UnionMixed is Union of streams sorted in possibly different directions, the result is sorted like stream1.
+// If the directions differ stream2 is reversed first, which materializes all of it in memory (O(len(stream2))),
+// so pass the smaller stream as stream2 when directions may differ
+func UnionMixed[T constraints.Ordered](stream1 SortedNumbersStream[T], ascA bool, stream2 SortedNumbersStream[T], ascB bool, opts ...Option) SortedNumbersStream[T] {
+	return Union(stream1, reconcile(stream2, ascB, ascA), ascA, opts...)
+}
+
+// IntersectMixed is Intersect of streams sorted in possibly different directions, the result is sorted like stream1.
+// If the directions differ stream2 is materialized in memory to be reversed, like in UnionMixed
+func IntersectMixed[T constraints.Ordered](stream1 SortedNumbersStream[T], ascA bool, stream2 SortedNumbersStream[T], ascB bool, opts ...Option) SortedNumbersStream[T] {
+	return Intersect(stream1, reconcile(stream2, ascB, ascA), ascA, opts...)
+}
+
+// DiffMixed is Diff of streams sorted in possibly different directions, the result is sorted like stream1.
+// If the directions differ the subtracted stream2 is materialized in memory to be reversed, like in UnionMixed
+func DiffMixed[T constraints.Ordered](stream1 SortedNumbersStream[T], ascA bool, stream2 SortedNumbersStream[T], ascB bool, opts ...Option) SortedNumbersStream[T] {
+	return Diff(stream1, reconcile(stream2, ascB, ascA), ascA, opts...)
+}
+
+// reconcile returns the stream sorted in the wanted direction, reversing it if asc differs
+func reconcile[T constraints.Ordered](stream SortedNumbersStream[T], asc, wantAsc bool) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	if asc == wantAsc {
+		return stream
+	}
+	mustMatchDirection(asc, stream)
+	return &directedStream[T]{reverse(stream), wantAsc}
+}
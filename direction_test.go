@@ -0,0 +1,115 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	type test struct {
+		items   []int
+		wantAsc bool
+		result  []int
+	}
+	tests := []test{
+		{[]int{}, true, []int{}},
+		{[]int{1}, false, []int{1}},
+		{[]int{1, 1, 1}, true, []int{1, 1, 1}},
+		{[]int{1, 2, 3}, true, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, false, []int{3, 2, 1}},
+		{[]int{3, 3, 2, 1}, true, []int{1, 2, 3, 3}},
+		{[]int{3, 3, 2, 1}, false, []int{3, 3, 2, 1}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			items := append([]int{}, tt.items...)
+			s := Normalize[int](NewSliceStream(items), tt.wantAsc)
+			require.Equal(t, tt.wantAsc, s.Asc())
+			require.EqualValues(t, tt.result, ToSlice[int](s))
+			require.EqualValues(t, tt.items, items) // the source is not modified
+		})
+	}
+}
+
+func TestNormalizeNaN(t *testing.T) {
+	nan := math.NaN()
+	asc := Normalize[float64](NewSliceStream([]float64{nan, nan, 1, 2}), true)
+	require.Equal(t, "[NaN NaN 1 2]", fmt.Sprint(ToSlice[float64](asc))) // NaN goes first, the stream is asc already
+	desc := Normalize[float64](NewSliceStream([]float64{nan, nan, 1, 2}), false)
+	require.Equal(t, "[2 1 NaN NaN]", fmt.Sprint(ToSlice[float64](desc)))
+}
+
+func TestNormalizeDirected(t *testing.T) {
+	desc := WithDirection[int](NewSliceStream([]int{1}), false) // trusted without reading
+	require.EqualValues(t, []int{1}, ToSlice[int](Normalize[int](desc, true)))
+
+	asc := NewRangeStream(0, 3, 1)
+	require.Same(t, asc, Normalize[int](asc, true))
+	require.EqualValues(t, []int{2, 1, 0}, ToSlice[int](Normalize[int](asc, false)))
+}
+
+func TestNormalizeMixedSources(t *testing.T) {
+	a := NewSliceStream([]int{1, 3, 5})
+	b := NewSliceStream([]int{6, 5, 4})
+	result := Union[int](Normalize[int](a, true), Normalize[int](b, true), true)
+	require.EqualValues(t, []int{1, 3, 4, 5, 6}, ToSlice(result))
+}
+
+func TestMixedDirections(t *testing.T) {
+	type test struct {
+		a          []int
+		ascA       bool
+		b          []int
+		ascB       bool
+		union      []int
+		intersect  []int
+		difference []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3}, true, []int{4, 3, 1}, false, []int{1, 2, 3, 4}, []int{1, 3}, []int{2}},
+		{[]int{3, 2, 1}, false, []int{1, 3, 4}, true, []int{4, 3, 2, 1}, []int{3, 1}, []int{2}},
+		{[]int{1, 2, 3}, true, []int{1, 3, 4}, true, []int{1, 2, 3, 4}, []int{1, 3}, []int{2}},
+		{[]int{1, 2}, true, []int{}, false, []int{1, 2}, []int{}, []int{1, 2}},
+		{[]int{}, false, []int{1, 2}, true, []int{2, 1}, []int{}, []int{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := func(items []int) SortedNumbersStream[int] { return NewSliceStream(items) }
+			require.EqualValues(t, tt.union, ToSlice(UnionMixed(s(tt.a), tt.ascA, s(tt.b), tt.ascB)))
+			require.EqualValues(t, tt.intersect, ToSlice(IntersectMixed(s(tt.a), tt.ascA, s(tt.b), tt.ascB)))
+			require.EqualValues(t, tt.difference, ToSlice(DiffMixed(s(tt.a), tt.ascA, s(tt.b), tt.ascB)))
+		})
+	}
+}
+
+func TestPushback(t *testing.T) {
+	a := NewPushback[int](NewSliceStream([]int{1, 3, 5}))
+	b := NewPushback[int](NewSliceStream([]int{2, 3, 4}))
+
+	// peek heads to pick the operand going first
+	headA, _ := a.Next()
+	headB, _ := b.Next()
+	require.Less(t, headA, headB)
+	a.Unread(headA)
+	b.Unread(headB)
+
+	require.EqualValues(t, []int{1, 2, 3, 4, 5}, ToSlice(Union[int](a, b, true)))
+}
+
+func TestPushbackSeveralItems(t *testing.T) {
+	s := NewPushback[int](NewSliceStream([]int{1, 2, 3, 4}))
+	first, _ := s.Next()
+	second, _ := s.Next()
+	s.Unread(second) // the last read goes back first
+	s.Unread(first)
+	require.EqualValues(t, []int{2, 3}, ToSlice(Intersect[int](s, NewSliceStream([]int{2, 3, 5}), true)))
+
+	empty := NewPushback[int](nil)
+	empty.Unread(7)
+	require.EqualValues(t, []int{7}, ToSlice[int](empty))
+}
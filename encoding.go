@@ -0,0 +1,279 @@
+package sorted_numeric_streams
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Encoded stream layout:
+// - 1 byte: encoding kind (delta-varint for integer types, gob for everything else)
+// - delta-varint only, 1 byte: the size of the integer type in bytes, the high bit is set for signed types
+// - blocks: uvarint(n) followed by n encoded items, an empty block (n=0) marks the end of the stream
+//
+// Delta-varint items are zig-zag varints of the difference to the previous item,
+// so both asc and desc streams with dense values take 1-2 bytes per item.
+// The integer layout makes decoding as a type of another size or signedness fail instead of reinterpreting the values.
+const (
+	encodingDeltaVarint byte = 1
+	encodingGob         byte = 2
+
+	encodeBlockSize = 256
+)
+
+// ErrUnknownEncoding is reported when decoded data was not produced by EncodeStream for the requested type
+var ErrUnknownEncoding = errors.New("unknown stream encoding")
+
+// ErrValueOverflow is reported when a decoded value does not fit the requested type,
+// e.g. a stream of int64 decoded as int8
+var ErrValueOverflow = errors.New("decoded value overflows the type")
+
+// Errorable is implemented by streams that may fail while reading (like I/O-backed ones)
+// once Next returns ok=false, Err tells if the stream was drained (nil) or failed
+type Errorable interface {
+	Err() error
+}
+
+// integerCodec converts values of the predeclared integer types to raw 64 bits and back,
+// fromBits reports fits=false when the bits do not fit T (e.g. corrupted data), layout identifies T in the header.
+// ok is false for other types, including named integer types, they are encoded with gob
+func integerCodec[T constraints.Ordered]() (layout byte, toBits func(T) uint64, fromBits func(uint64) (v T, fits bool), ok bool) {
+	switch any(*new(T)).(type) {
+	case int:
+		return integerBits[T, int]()
+	case int8:
+		return integerBits[T, int8]()
+	case int16:
+		return integerBits[T, int16]()
+	case int32:
+		return integerBits[T, int32]()
+	case int64:
+		return integerBits[T, int64]()
+	case uint:
+		return integerBits[T, uint]()
+	case uint8:
+		return integerBits[T, uint8]()
+	case uint16:
+		return integerBits[T, uint16]()
+	case uint32:
+		return integerBits[T, uint32]()
+	case uint64:
+		return integerBits[T, uint64]()
+	case uintptr:
+		return integerBits[T, uintptr]()
+	}
+	return 0, nil, nil, false
+}
+
+// integerBits is the codec of T which is I, signed values are sign-extended to 64 bits
+func integerBits[T any, I constraints.Integer]() (byte, func(T) uint64, func(uint64) (T, bool), bool) {
+	var zero I
+	layout := byte(unsafe.Sizeof(zero))
+	if zero-1 < zero {
+		layout |= 0x80
+	}
+	toBits := func(v T) uint64 { return uint64(*(*I)(unsafe.Pointer(&v))) }
+	fromBits := func(bits uint64) (T, bool) {
+		i := I(bits)
+		return *(*T)(unsafe.Pointer(&i)), uint64(i) == bits
+	}
+	return layout, toBits, fromBits, true
+}
+
+// EncodeStream drains the stream and writes it to w, so the result can be cached and replayed with DecodeStream
+// Predeclared integer types use compact delta-varint encoding, other types (named ones too) fall back to gob
+// If the stream is Errorable, its error is returned
+func EncodeStream[T constraints.Ordered](stream SortedNumbersStream[T], w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	layout, toBits, _, isInt := integerCodec[T]()
+
+	header := []byte{encodingGob}
+	if isInt {
+		header = []byte{encodingDeltaVarint, layout}
+	}
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	var (
+		enc    = gob.NewEncoder(bw)
+		block  = make([]T, 0, encodeBlockSize)
+		varint = make([]byte, binary.MaxVarintLen64)
+		prev   uint64
+	)
+	writeBlock := func() error {
+		n := binary.PutUvarint(varint, uint64(len(block)))
+		if _, err := bw.Write(varint[:n]); err != nil {
+			return err
+		}
+		if len(block) == 0 {
+			return nil
+		}
+		if !isInt {
+			return enc.Encode(block)
+		}
+		for _, item := range block {
+			bits := toBits(item)
+			n = binary.PutVarint(varint, int64(bits-prev))
+			if _, err := bw.Write(varint[:n]); err != nil {
+				return err
+			}
+			prev = bits
+		}
+		return nil
+	}
+
+	for {
+		item, ok := stream.Next()
+		if !ok {
+			break
+		}
+		block = append(block, item)
+		if len(block) == cap(block) {
+			if err := writeBlock(); err != nil {
+				return err
+			}
+			block = block[:0]
+		}
+	}
+	if e, ok := stream.(Errorable); ok && e.Err() != nil {
+		return e.Err()
+	}
+
+	if len(block) > 0 {
+		if err := writeBlock(); err != nil {
+			return err
+		}
+		block = block[:0]
+	}
+	if err := writeBlock(); err != nil { // terminating empty block
+		return err
+	}
+	return bw.Flush()
+}
+
+// DecodedStream lazily reads a stream written by EncodeStream
+type DecodedStream[T constraints.Ordered] struct {
+	r         *bufio.Reader
+	dec       *gob.Decoder
+	fromBits  func(uint64) (T, bool)
+	kind      byte
+	block     []T
+	remaining uint64 // items left in the current varint block
+	prev      uint64
+	done      bool
+	err       error
+}
+
+func (s *DecodedStream[T]) Next() (item T, ok bool) {
+	if s.done {
+		return
+	}
+	if s.kind == 0 && !s.readHeader() {
+		return
+	}
+	if s.kind == encodingGob {
+		for len(s.block) == 0 {
+			if !s.readGobBlock() {
+				return
+			}
+		}
+		item, s.block = s.block[0], s.block[1:]
+		return item, true
+	}
+
+	if s.remaining == 0 {
+		n, err := binary.ReadUvarint(s.r)
+		if err != nil {
+			s.fail(err)
+			return
+		}
+		if n == 0 {
+			s.done = true
+			return
+		}
+		s.remaining = n
+	}
+	delta, err := binary.ReadVarint(s.r)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	s.remaining--
+	s.prev += uint64(delta)
+	if item, ok = s.fromBits(s.prev); !ok {
+		s.fail(fmt.Errorf("%w: %T", ErrValueOverflow, item))
+		var zero T
+		return zero, false
+	}
+	return item, true
+}
+
+// Err returns the first decoding error, nil if the stream was read to the end successfully
+func (s *DecodedStream[T]) Err() error { return s.err }
+
+func (s *DecodedStream[T]) fail(err error) {
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF // the terminating block was never seen
+	}
+	s.err, s.done = err, true
+}
+
+func (s *DecodedStream[T]) readHeader() bool {
+	kind, err := s.r.ReadByte()
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	layout, _, fromBits, isInt := integerCodec[T]()
+	switch {
+	case kind == encodingDeltaVarint && isInt:
+		encoded, err := s.r.ReadByte()
+		if err != nil {
+			s.fail(err)
+			return false
+		}
+		if encoded != layout {
+			s.fail(fmt.Errorf("%w: integer layout %#x for %T", ErrUnknownEncoding, encoded, *new(T)))
+			return false
+		}
+		s.fromBits = fromBits
+	case kind == encodingGob && !isInt:
+		s.dec = gob.NewDecoder(s.r)
+	default:
+		s.fail(fmt.Errorf("%w: kind %d for %T", ErrUnknownEncoding, kind, *new(T)))
+		return false
+	}
+	s.kind = kind
+	return true
+}
+
+func (s *DecodedStream[T]) readGobBlock() bool {
+	n, err := binary.ReadUvarint(s.r)
+	if err != nil {
+		s.fail(err)
+		return false
+	}
+	if n == 0 {
+		s.done = true
+		return false
+	}
+	s.block = s.block[:0]
+	if err = s.dec.Decode(&s.block); err != nil {
+		s.fail(err)
+		return false
+	}
+	return true
+}
+
+// DecodeStream returns a stream reading items written by EncodeStream from r
+// Decoding is lazy, check Err once the stream is drained
+func DecodeStream[T constraints.Ordered](r io.Reader) *DecodedStream[T] {
+	return &DecodedStream[T]{r: bufio.NewReader(r)}
+}
@@ -0,0 +1,126 @@
+package sorted_numeric_streams
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	type test struct {
+		items []int64
+	}
+	large := make([]int64, 1000)
+	for i := range large {
+		large[i] = int64(i * 3)
+	}
+	tests := []test{
+		{[]int64{}},
+		{[]int64{0}},
+		{[]int64{1, 2, 3}},
+		{[]int64{3, 2, 1, -100}}, // desc
+		{[]int64{-1 << 63, 0, 1<<63 - 1}},
+		{large},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, EncodeStream[int64](NewSliceStream(tt.items), &buf))
+			decoded := DecodeStream[int64](&buf)
+			require.EqualValues(t, tt.items, ToSlice[int64](decoded))
+			require.NoError(t, decoded.Err())
+		})
+	}
+}
+
+func TestEncodeDecodeUnsigned(t *testing.T) {
+	items := []uint64{0, 1, 1 << 63, 1<<64 - 1}
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[uint64](NewSliceStream(items), &buf))
+	require.EqualValues(t, items, ToSlice[uint64](DecodeStream[uint64](&buf)))
+}
+
+func TestEncodeDecodeGob(t *testing.T) {
+	items := []string{"a", "b", "c"}
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[string](NewSliceStream(items), &buf))
+	decoded := DecodeStream[string](&buf)
+	require.EqualValues(t, items, ToSlice[string](decoded))
+	require.NoError(t, decoded.Err())
+}
+
+func TestEncodeIsCompact(t *testing.T) {
+	items := make([]int, 1000)
+	for i := range items {
+		items[i] = 1_000_000 + i
+	}
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[int](NewSliceStream(items), &buf))
+	require.Less(t, buf.Len(), 1100) // 1 byte per delta plus block headers
+}
+
+func TestDecodeErrors(t *testing.T) {
+	// truncated data
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[int](NewSliceStream([]int{1, 2, 3}), &buf))
+	truncated := DecodeStream[int](bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice[int](truncated))
+	require.Error(t, truncated.Err())
+
+	// type mismatch
+	mismatched := DecodeStream[string](bytes.NewReader(buf.Bytes()))
+	require.EqualValues(t, []string{}, ToSlice[string](mismatched))
+	require.ErrorIs(t, mismatched.Err(), ErrUnknownEncoding)
+}
+
+func TestDecodeLayoutMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[int64](NewSliceStream([]int64{-1, 127, 128}), &buf))
+	narrow := DecodeStream[int8](bytes.NewReader(buf.Bytes()))
+	require.EqualValues(t, []int8{}, ToSlice[int8](narrow))
+	require.ErrorIs(t, narrow.Err(), ErrUnknownEncoding)
+
+	unsigned := DecodeStream[uint64](bytes.NewReader(buf.Bytes())) // same width, another signedness
+	require.EqualValues(t, []uint64{}, ToSlice[uint64](unsigned))
+	require.ErrorIs(t, unsigned.Err(), ErrUnknownEncoding)
+
+	buf.Reset()
+	require.NoError(t, EncodeStream[uint64](NewSliceStream([]uint64{1<<64 - 1}), &buf))
+	signed := DecodeStream[int64](bytes.NewReader(buf.Bytes()))
+	require.EqualValues(t, []int64{}, ToSlice[int64](signed))
+	require.ErrorIs(t, signed.Err(), ErrUnknownEncoding)
+}
+
+func TestDecodeOverflow(t *testing.T) {
+	// corrupted int8 data: the deltas go past 127
+	data := []byte{encodingDeltaVarint, 0x81}
+	data = binary.AppendUvarint(data, 2)
+	data = binary.AppendVarint(data, 127)
+	data = binary.AppendVarint(data, 1)
+	decoded := DecodeStream[int8](bytes.NewReader(data))
+	require.EqualValues(t, []int8{127}, ToSlice[int8](decoded))
+	require.ErrorIs(t, decoded.Err(), ErrValueOverflow)
+}
+
+func TestEncodeDecodeNarrowTypes(t *testing.T) {
+	var buf bytes.Buffer
+	items := []int8{-128, -1, 0, 127}
+	require.NoError(t, EncodeStream[int8](NewSliceStream(items), &buf))
+	require.EqualValues(t, items, ToSlice[int8](DecodeStream[int8](&buf)))
+
+	buf.Reset()
+	require.NoError(t, EncodeStream[uint16](NewSliceStream([]uint16{0, 1, 65535}), &buf))
+	require.EqualValues(t, []uint16{0, 1, 65535}, ToSlice[uint16](DecodeStream[uint16](&buf)))
+}
+
+func TestEncodeDecodeNamedType(t *testing.T) {
+	type id int
+	items := []id{1, 2, 3}
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[id](NewSliceStream(items), &buf))
+	require.EqualValues(t, items, ToSlice[id](DecodeStream[id](&buf)))
+}
@@ -0,0 +1,168 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"sort"
+
+	"golang.org/x/exp/constraints"
+)
+
+// ErrNoUniverse is returned when an expression negates a set that is not subtracted from anything
+// (e.g. Not(a) or Or(a, Not(b))), so the universe of all items is required to evaluate it
+var ErrNoUniverse = errors.New("expression requires a universe to negate")
+
+type exprKind int
+
+const (
+	exprSource exprKind = iota
+	exprAnd
+	exprOr
+	exprNot
+)
+
+// Expr is a boolean expression over sorted streams, e.g. And(Or(a, b), Not(c))
+// It is compiled into a single pull-based stream (see Compose), so it uses no goroutines
+type Expr[T constraints.Ordered] struct {
+	kind     exprKind
+	stream   SortedNumbersStream[T]
+	operands []*Expr[T]
+}
+
+// Source makes a stream an expression operand
+func Source[T constraints.Ordered](stream SortedNumbersStream[T]) *Expr[T] {
+	return &Expr[T]{kind: exprSource, stream: stream}
+}
+
+// And matches items present in every operand
+func And[T constraints.Ordered](operands ...*Expr[T]) *Expr[T] {
+	return &Expr[T]{kind: exprAnd, operands: operands}
+}
+
+// Or matches items present in any operand
+func Or[T constraints.Ordered](operands ...*Expr[T]) *Expr[T] {
+	return &Expr[T]{kind: exprOr, operands: operands}
+}
+
+// Not matches items absent from the operand: inside And it subtracts the operand from the other operands,
+// elsewhere it subtracts the operand from the universe
+func Not[T constraints.Ordered](operand *Expr[T]) *Expr[T] {
+	return &Expr[T]{kind: exprNot, operands: []*Expr[T]{operand}}
+}
+
+// Compile turns the expression into a stream.
+// Negations are pushed toward the leaves (De Morgan's laws) so most of them become a Diff from sibling operands,
+// intersections start with the smallest Sized operands.
+// universe makes the stream of all items for negations that can't be turned into a Diff, it may be nil otherwise.
+// Every Source stream must be used once in the expression since streams are single-use.
+func (e *Expr[T]) Compile(asc bool, universe StreamFactory[T]) (SortedNumbersStream[T], error) {
+	c := &exprCompiler[T]{op: Compose[T](asc), universe: universe}
+	return c.compile(e.pushNegations(false))
+}
+
+// pushNegations returns the equivalent expression where Not only wraps sources
+func (e *Expr[T]) pushNegations(negate bool) *Expr[T] {
+	switch e.kind {
+	case exprNot:
+		return e.operands[0].pushNegations(!negate)
+	case exprAnd, exprOr:
+		kind := e.kind
+		if negate { // De Morgan's laws
+			kind = map[exprKind]exprKind{exprAnd: exprOr, exprOr: exprAnd}[e.kind]
+		}
+		operands := make([]*Expr[T], 0, len(e.operands))
+		for _, operand := range e.operands {
+			operand = operand.pushNegations(negate)
+			if operand.kind == kind { // flatten, so And(a, And(Not(b), Not(c))) becomes a Diff chain
+				operands = append(operands, operand.operands...)
+				continue
+			}
+			operands = append(operands, operand)
+		}
+		return &Expr[T]{kind: kind, operands: operands}
+	}
+	if negate {
+		return Not(e)
+	}
+	return e
+}
+
+type exprCompiler[T constraints.Ordered] struct {
+	op       *Composer[T]
+	universe StreamFactory[T]
+}
+
+func (c *exprCompiler[T]) compile(e *Expr[T]) (SortedNumbersStream[T], error) {
+	switch e.kind {
+	case exprSource:
+		return e.stream, nil
+	case exprNot:
+		return c.negate(e.operands[0])
+	case exprOr:
+		var result SortedNumbersStream[T]
+		for _, operand := range e.operands {
+			s, err := c.compile(operand)
+			if err != nil {
+				return nil, err
+			}
+			if result == nil {
+				result = s
+			} else {
+				result = c.op.Union(result, s)
+			}
+		}
+		if result == nil { // Or() matches nothing, like And() matches the universe
+			result = &directedStream[T]{NewSliceStream[T](nil), c.op.asc}
+		}
+		return result, nil
+	}
+
+	// AND: intersect positive operands, then subtract negated ones
+	var positives, negatives []SortedNumbersStream[T]
+	for _, operand := range e.operands {
+		if operand.kind == exprNot {
+			s, err := c.compile(operand.operands[0])
+			if err != nil {
+				return nil, err
+			}
+			negatives = append(negatives, s)
+			continue
+		}
+		s, err := c.compile(operand)
+		if err != nil {
+			return nil, err
+		}
+		positives = append(positives, s)
+	}
+	if len(positives) == 0 {
+		if c.universe == nil {
+			return nil, ErrNoUniverse
+		}
+		positives = append(positives, c.universe())
+	}
+
+	sort.SliceStable(positives, func(i, j int) bool { // smaller operands first, unknown sizes last
+		ni, knownI := sizeOf(positives[i])
+		nj, knownJ := sizeOf(positives[j])
+		return knownI && (!knownJ || ni < nj)
+	})
+	result := positives[0]
+	for _, s := range positives[1:] {
+		result = c.op.Intersect(result, s)
+	}
+	for _, s := range negatives {
+		result = c.op.Diff(result, s)
+	}
+	return result, nil
+}
+
+// negate subtracts the operand from the universe
+func (c *exprCompiler[T]) negate(operand *Expr[T]) (SortedNumbersStream[T], error) {
+	if c.universe == nil {
+		return nil, ErrNoUniverse
+	}
+	s, err := c.compile(operand)
+	if err != nil {
+		return nil, err
+	}
+	return c.op.Diff(c.universe(), s), nil
+}
@@ -0,0 +1,63 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpr(t *testing.T) {
+	s := func(items ...int) *Expr[int] { return Source[int](NewSliceStream(items)) }
+	universe := func() SortedNumbersStream[int] { return NewRangeStream(0, 10, 1) }
+
+	type test struct {
+		name     string
+		expr     *Expr[int]
+		universe StreamFactory[int]
+		result   []int
+	}
+	tests := []test{
+		{"source", s(1, 2), nil, []int{1, 2}},
+		{"and", And(s(1, 2, 3), s(2, 3, 4), s(3, 4)), nil, []int{3}},
+		{"or", Or(s(1), s(3), s(2)), nil, []int{1, 2, 3}},
+		{"and not", And(Or(s(1, 2), s(3, 4)), Not(s(2, 3))), nil, []int{1, 4}},
+		{"and not only", And(Not(s(1, 2)), Not(s(5))), universe, []int{0, 3, 4, 6, 7, 8, 9}},
+		{"not", Not(s(0, 1, 2, 3, 4)), universe, []int{5, 6, 7, 8, 9}},
+		{"double not", Not(Not(s(1, 2))), nil, []int{1, 2}},
+		{"not or", And(s(1, 2, 3, 4), Not(Or(s(1), s(3)))), nil, []int{2, 4}}, // becomes a AND NOT 1 AND NOT 3
+		{"not and", Not(And(s(1, 2, 3), s(2, 3, 4))), universe, []int{0, 1, 4, 5, 6, 7, 8, 9}},
+		{"or not", Or(s(1), Not(s(0, 1, 2, 3, 4, 5, 6, 7, 8))), universe, []int{1, 9}},
+		{"empty or", Or[int](), nil, []int{}},
+		{"and empty or", And(s(1, 2), Or[int]()), nil, []int{}},
+		{"or empty or", Or(s(1, 2), Or[int]()), nil, []int{1, 2}},
+		{"not empty or", Not(Or[int]()), universe, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := tt.expr.Compile(true, tt.universe)
+			require.NoError(t, err)
+			require.EqualValues(t, tt.result, ToSlice(result))
+		})
+	}
+}
+
+func TestExprWithoutUniverse(t *testing.T) {
+	_, err := Not(Source[int](NewSliceStream([]int{1}))).Compile(true, nil)
+	require.ErrorIs(t, err, ErrNoUniverse)
+
+	_, err = Or(Source[int](NewSliceStream([]int{1})), Not(Source[int](NewSliceStream([]int{2})))).Compile(true, nil)
+	require.ErrorIs(t, err, ErrNoUniverse)
+}
+
+func TestExprIntersectOrder(t *testing.T) {
+	big := NewRangeStream(0, 1000, 1)
+	small := NewSliceStream([]int{5, 500})
+	result, err := And(Source[int](big), Source[int](small)).Compile(true, nil)
+	require.NoError(t, err)
+
+	// the smallest operand goes first
+	merger := result.(*pullOperation[int]).merger
+	require.Same(t, small, merger.stream1)
+	require.EqualValues(t, []int{5, 500}, ToSlice(result))
+}
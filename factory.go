@@ -0,0 +1,77 @@
+package sorted_numeric_streams
+
+import (
+	"os"
+
+	"golang.org/x/exp/constraints"
+)
+
+// StreamFactory makes a fresh stream over the same data on every call
+// Streams are single-use, so factories allow to run the same operation many times (e.g. for A/B testing)
+type StreamFactory[T constraints.Ordered] func() SortedNumbersStream[T]
+
+// SliceFactory returns a factory of SliceStreams over the same slice
+func SliceFactory[T constraints.Ordered](slice []T) StreamFactory[T] {
+	return func() SortedNumbersStream[T] { return NewSliceStream(slice) }
+}
+
+// FileFactory returns a factory that reopens a file written by EncodeStream on every call
+// The file is opened on the first Next and closed once the stream is drained, failures are reported via Errorable
+func FileFactory[T constraints.Ordered](path string) StreamFactory[T] {
+	return func() SortedNumbersStream[T] { return &fileStream[T]{path: path} }
+}
+
+// UnionFactory returns a factory of Union results made from fresh operand streams
+func UnionFactory[T constraints.Ordered](f1, f2 StreamFactory[T], asc bool) StreamFactory[T] {
+	return func() SortedNumbersStream[T] { return Union[T](f1(), f2(), asc) }
+}
+
+// IntersectFactory returns a factory of Intersect results made from fresh operand streams
+func IntersectFactory[T constraints.Ordered](f1, f2 StreamFactory[T], asc bool) StreamFactory[T] {
+	return func() SortedNumbersStream[T] { return Intersect[T](f1(), f2(), asc) }
+}
+
+// DiffFactory returns a factory of Diff results made from fresh operand streams
+func DiffFactory[T constraints.Ordered](f1, f2 StreamFactory[T], asc bool) StreamFactory[T] {
+	return func() SortedNumbersStream[T] { return Diff[T](f1(), f2(), asc) }
+}
+
+// fileStream decodes a file lazily and closes it once drained
+type fileStream[T constraints.Ordered] struct {
+	path    string
+	file    *os.File
+	decoded *DecodedStream[T]
+	done    bool
+	err     error
+}
+
+func (s *fileStream[T]) Next() (item T, ok bool) {
+	if s.done {
+		return
+	}
+	if s.file == nil {
+		if s.file, s.err = os.Open(s.path); s.err != nil {
+			s.done = true
+			return
+		}
+		s.decoded = DecodeStream[T](s.file)
+	}
+	if item, ok = s.decoded.Next(); !ok {
+		s.done = true
+		s.err = s.decoded.Err()
+		if err := s.file.Close(); s.err == nil {
+			s.err = err
+		}
+	}
+	return
+}
+
+func (s *fileStream[T]) Err() error { return s.err }
+
+// close releases the file of a stream which is not drained, the stream ends
+func (s *fileStream[T]) close() {
+	if s.file != nil && !s.done {
+		s.file.Close()
+	}
+	s.done = true
+}
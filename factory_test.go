@@ -0,0 +1,46 @@
+package sorted_numeric_streams
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactoryRepeatable(t *testing.T) {
+	a := SliceFactory([]int{1, 2, 3})
+	b := SliceFactory([]int{2, 3, 4})
+
+	intersect := IntersectFactory(a, b, true)
+	require.EqualValues(t, []int{2, 3}, ToSlice(intersect()))
+	require.EqualValues(t, []int{2, 3}, ToSlice(intersect())) // fresh operands every call
+
+	union := UnionFactory(a, b, true)
+	require.EqualValues(t, []int{1, 2, 3, 4}, ToSlice(union()))
+	require.EqualValues(t, []int{1, 2, 3, 4}, ToSlice(union()))
+
+	// factories compose: (a and b) and not [3]
+	diff := DiffFactory(intersect, SliceFactory([]int{3}), true)
+	require.EqualValues(t, []int{2}, ToSlice(diff()))
+	require.EqualValues(t, []int{2}, ToSlice(diff()))
+}
+
+func TestFileFactory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stream.bin")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	require.NoError(t, EncodeStream[int](NewSliceStream([]int{1, 2, 3}), f))
+	require.NoError(t, f.Close())
+
+	factory := FileFactory[int](path)
+	for i := 0; i < 2; i++ {
+		s := factory()
+		require.EqualValues(t, []int{1, 2, 3}, ToSlice(s))
+		require.NoError(t, s.(Errorable).Err())
+	}
+
+	missing := FileFactory[int](filepath.Join(t.TempDir(), "missing.bin"))()
+	require.EqualValues(t, []int{}, ToSlice(missing))
+	require.Error(t, missing.(Errorable).Err())
+}
@@ -0,0 +1,64 @@
+package sorted_numeric_streams
+
+import "math"
+
+// IntersectApprox returns the stream of elements of stream1 that have a match in stream2 within epsilon: |a-b| <= epsilon
+// This tolerates floating-point noise which makes exact Intersect miss values
+//
+// Note that epsilon-equality is not transitive: every element is matched at most once, so in
+// [1.0, 1.1] and [1.05] (epsilon=0.06) only 1.0 is emitted, even though 1.1 is within epsilon of 1.05 too.
+// The emitted element is always the one from stream1.
+func IntersectApprox(stream1, stream2 SortedNumbersStream[float64], asc bool, epsilon float64) SortedNumbersStream[float64] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	result := NewChannelStream[float64]()
+	intersectOperation := func(a, b *float64) {
+		if item := intersectPick(a, b); item != nil {
+			result.Push(*item)
+		}
+	}
+
+	m := newMerger(stream1, stream2, approxCompare(epsilon), intersectStop, asc)
+
+	go func() {
+		defer result.closeOnPanic()
+		m.run(intersectOperation)
+		result.Close()
+	}()
+
+	return &directedStream[float64]{result, asc}
+}
+
+// approxCompare treats values within epsilon as equal
+func approxCompare(epsilon float64) func(a, b float64) int {
+	return func(a, b float64) int {
+		if math.Abs(a-b) <= epsilon {
+			return 0
+		} else if a < b {
+			return -1
+		}
+		return 1
+	}
+}
+
+// EqualApprox tells if the streams have the same number of values and every value is within epsilon of the value
+// at the same position of the other stream (NaN matches NaN). Values are paired by position, not chained like
+// in IntersectApprox, so [1.0, 1.1] is not equal to [1.05] even though both values are close to 1.05, and
+// drifting values can't add up beyond epsilon. Reading stops at the first value out of tolerance
+func EqualApprox(stream1, stream2 SortedNumbersStream[float64], asc bool, epsilon float64) bool {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	for {
+		a, okA := stream1.Next()
+		b, okB := stream2.Next()
+		if okA != okB {
+			return false // different lengths
+		}
+		if !okA {
+			return true
+		}
+		if !(math.Abs(a-b) <= epsilon || math.IsNaN(a) && math.IsNaN(b)) {
+			return false
+		}
+	}
+}
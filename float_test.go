@@ -0,0 +1,104 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntersectApprox(t *testing.T) {
+	x, y := 0.1, 0.2
+	noisy := x + y // 0.30000000000000004
+	type test struct {
+		a, b, result []float64
+		asc          bool
+		epsilon      float64
+	}
+	tests := []test{
+		{[]float64{}, []float64{1}, []float64{}, true, 0.1},
+		{[]float64{noisy}, []float64{0.3}, []float64{noisy}, true, 1e-9},
+		{[]float64{1, 2, 3}, []float64{1.04, 2.5, 3.01}, []float64{1, 3}, true, 0.05},
+		{[]float64{1.0, 1.1}, []float64{1.05}, []float64{1.0}, true, 0.06}, // not transitive
+		{[]float64{3, 2, 1}, []float64{3.01, 1.5, 0.99}, []float64{3, 1}, false, 0.05},
+		{[]float64{1, 2}, []float64{1, 2}, []float64{1, 2}, true, 0},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			a := NewSliceStream(tt.a)
+			b := NewSliceStream(tt.b)
+			c := IntersectApprox(a, b, tt.asc, tt.epsilon)
+			require.EqualValues(t, tt.result, ToSlice(c))
+		})
+	}
+
+	require.NotEqualValues(t, []float64{noisy}, ToSlice(Intersect[float64](NewSliceStream([]float64{noisy}), NewSliceStream([]float64{0.3}), true)))
+}
+
+func TestEqualApprox(t *testing.T) {
+	type test struct {
+		a, b    []float64
+		asc     bool
+		epsilon float64
+		equal   bool
+	}
+	tests := []test{
+		{[]float64{}, []float64{}, true, 0.1, true},
+		{[]float64{1}, []float64{}, true, 0.1, false},
+		{[]float64{}, []float64{1}, true, 0.1, false},
+		{[]float64{0.1 + 0.2, 1}, []float64{0.3, 1}, true, 1e-9, true},
+		{[]float64{3, 2.001}, []float64{3.001, 2}, false, 0.01, true},
+		{[]float64{1, 2, 3}, []float64{1, 2, 3.5}, true, 0.1, false},
+		{[]float64{1.0, 1.1}, []float64{1.05}, true, 0.06, false},
+		{[]float64{1.0, 1.05}, []float64{1.05, 1.1}, true, 0.06, true},   // every pair is close
+		{[]float64{1.0, 1.05}, []float64{1.05, 1.12}, true, 0.06, false}, // the drift is not accumulated
+		{[]float64{math.NaN(), 1}, []float64{math.NaN(), 1}, true, 0, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.Equal(t, tt.equal, EqualApprox(NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc, tt.epsilon))
+		})
+	}
+}
+
+func TestEqualApproxStopsEarly(t *testing.T) {
+	a := NewSliceStream([]float64{1, 5, 6, 7})
+	require.False(t, EqualApprox(a, NewSliceStream([]float64{1, 2, 6, 7}), true, 0.1))
+	require.EqualValues(t, []float64{6, 7}, ToSlice[float64](a))
+}
+
+func TestOperationsWithNaN(t *testing.T) {
+	nan := math.NaN()
+	a := func() SortedNumbersStream[float64] { return NewSliceStream([]float64{nan, 1, 2}) } // as slices.Sort orders them
+	b := func() SortedNumbersStream[float64] { return NewSliceStream([]float64{nan, nan, 2, 3}) }
+
+	done := make(chan struct{})
+	var union, intersection, diff []float64
+	go func() {
+		defer close(done)
+		union = ToSlice(Union(a(), b(), true))
+		intersection = ToSlice(Intersect(a(), b(), true))
+		diff = ToSlice(Diff(a(), b(), true))
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("operations on NaN hang")
+	}
+
+	require.Len(t, union, 5)
+	require.True(t, math.IsNaN(union[0]) && math.IsNaN(union[1]))
+	require.EqualValues(t, []float64{1, 2, 3}, union[2:])
+	require.Len(t, intersection, 2)
+	require.True(t, math.IsNaN(intersection[0]))
+	require.EqualValues(t, 2.0, intersection[1])
+	require.EqualValues(t, []float64{1}, diff)
+
+	desc := ToSlice(Union(NewSliceStream([]float64{2, nan}), NewSliceStream([]float64{3, 1}), false))
+	require.EqualValues(t, []float64{3, 2, 1}, desc[:3])
+	require.True(t, math.IsNaN(desc[3]))
+}
@@ -0,0 +1,41 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// Stream wraps a stream with its direction and options, so operations chain without repeating them:
+//
+//	result := Fluent(a, true).And(b).AndNot(c).Collect() // same as Diff(Intersect(a, b, true), c, true)
+type Stream[T constraints.Ordered] struct {
+	SortedNumbersStream[T]
+	asc  bool
+	opts []Option
+}
+
+// Fluent wraps the stream sorted in the given direction, opts are passed to every chained operation
+func Fluent[T constraints.Ordered](stream SortedNumbersStream[T], asc bool, opts ...Option) *Stream[T] {
+	return &Stream[T]{orEmpty(stream), asc, opts}
+}
+
+// Or returns the Union of the stream and other
+func (s *Stream[T]) Or(other SortedNumbersStream[T]) *Stream[T] {
+	return s.chain(Union(s.SortedNumbersStream, other, s.asc, s.opts...))
+}
+
+// And returns the Intersect of the stream and other
+func (s *Stream[T]) And(other SortedNumbersStream[T]) *Stream[T] {
+	return s.chain(Intersect(s.SortedNumbersStream, other, s.asc, s.opts...))
+}
+
+// AndNot returns the Diff of the stream and other
+func (s *Stream[T]) AndNot(other SortedNumbersStream[T]) *Stream[T] {
+	return s.chain(Diff(s.SortedNumbersStream, other, s.asc, s.opts...))
+}
+
+func (s *Stream[T]) chain(result SortedNumbersStream[T]) *Stream[T] {
+	return &Stream[T]{result, s.asc, s.opts}
+}
+
+func (s *Stream[T]) Asc() bool { return s.asc }
+
+// Collect reads the rest of the stream to a slice
+func (s *Stream[T]) Collect() []T { return ToSlice[T](s.SortedNumbersStream) }
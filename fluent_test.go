@@ -0,0 +1,52 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFluent(t *testing.T) {
+	type test struct {
+		name   string
+		build  func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int]
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{"and not", func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int] { return Fluent(a, asc).And(b).AndNot(c) }, true, []int{2}},
+		{"or", func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int] { return Fluent(b, asc).Or(c).Or(a) }, true, []int{1, 2, 3}},
+		{"desc", func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int] { return Fluent(a, asc).AndNot(b).Or(c) }, false, []int{3, 1}},
+		{"nil", func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int] {
+			return Fluent[int](nil, asc).Or(a).And(b)
+		}, true, []int{2, 3}},
+		{"pull backend", func(a, b, c SortedNumbersStream[int], asc bool) *Stream[int] {
+			return Fluent(a, asc, WithPullBackend()).And(b).AndNot(c)
+		}, true, []int{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items := func(items ...int) SortedNumbersStream[int] {
+				if !tt.asc {
+					for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+						items[i], items[j] = items[j], items[i]
+					}
+				}
+				return NewSliceStream(items)
+			}
+			result := tt.build(items(1, 2, 3), items(2, 3), items(3), tt.asc)
+			require.Equal(t, tt.asc, result.Asc())
+			require.EqualValues(t, tt.result, result.Collect())
+		})
+	}
+}
+
+func TestFluentMatchesComposition(t *testing.T) {
+	a := NewSliceStream([]int{1, 2, 3})
+	b := NewSliceStream([]int{2, 3})
+	c := NewSliceStream([]int{3})
+	nested := ToSlice(Diff[int](Intersect[int](a, b, true), c, true))
+
+	a, b, c = NewSliceStream([]int{1, 2, 3}), NewSliceStream([]int{2, 3}), NewSliceStream([]int{3})
+	require.EqualValues(t, nested, Fluent[int](a, true).And(b).AndNot(c).Collect())
+}
@@ -0,0 +1,92 @@
+package sorted_numeric_streams
+
+import "errors"
+
+// Comparator variants work with any type ordered by cmp (like slices.SortFunc does):
+// cmp returns a negative number if a < b, zero if a and b are equal and a positive number if a > b.
+// cmp must be a valid ordering: a comparator disagreeing with itself when arguments are swapped stops the operation
+// with ErrBrokenComparator (reported by the Err method of the result) instead of producing a misordered result
+
+// ErrBrokenComparator is reported when cmp(a, b) and cmp(b, a) contradict each other
+var ErrBrokenComparator = errors.New("comparator is not a valid ordering")
+
+// UnionFunc is Union for streams ordered by cmp
+// When items are equal, resolve decides which value goes to the result (e.g. to merge payloads or for last-write-wins),
+// nil resolve keeps the left value
+func UnionFunc[T any](stream1, stream2 SortedNumbersStream[T], asc bool, cmp func(a, b T) int, resolve func(a, b T) T) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	if resolve == nil {
+		resolve = func(a, b T) T { return a }
+	}
+
+	return runFunc(stream1, stream2, asc, cmp, unionStop, func(a, b *T, push func(T)) {
+		if a != nil && b != nil {
+			push(resolve(*a, *b))
+		} else if a != nil {
+			push(*a)
+		} else {
+			push(*b)
+		}
+	})
+}
+
+// IntersectFunc is Intersect for streams ordered by cmp, items of stream1 are emitted
+func IntersectFunc[T any](stream1, stream2 SortedNumbersStream[T], asc bool, cmp func(a, b T) int) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	return runFunc(stream1, stream2, asc, cmp, intersectStop, func(a, b *T, push func(T)) {
+		if a != nil && b != nil {
+			push(*a)
+		}
+	})
+}
+
+// DiffFunc is Diff for streams ordered by cmp, every occurrence of an item found in stream2 is removed
+func DiffFunc[T any](stream1, stream2 SortedNumbersStream[T], asc bool, cmp func(a, b T) int) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	var (
+		removed    T
+		hasRemoved bool
+	)
+	return runFunc(stream1, stream2, asc, cmp, diffStop, func(a, b *T, push func(T)) {
+		if a != nil && b != nil {
+			removed, hasRemoved = *a, true
+		} else if a != nil && !(hasRemoved && cmp(*a, removed) == 0) {
+			push(*a)
+		}
+	})
+}
+
+// runFunc runs the merge of a comparator variant in a goroutine, op pushes items of every position to the result
+func runFunc[T any](stream1, stream2 SortedNumbersStream[T], asc bool, cmp func(a, b T) int, stop shouldStop, op func(a, b *T, push func(T))) SortedNumbersStream[T] {
+	result := NewChannelStream[T]()
+	m := newMerger(stream1, stream2, cmp, stop, asc)
+	m.checkCmp = true
+	go func() {
+		defer result.closeOnPanic()
+		m.run(func(a, b *T) { op(a, b, result.Push) })
+		result.Close()
+	}()
+
+	return &funcResult[T]{result, asc, m}
+}
+
+// funcResult is the result of a comparator variant, it reports a broken comparator once drained
+type funcResult[T any] struct {
+	SortedNumbersStream[T]
+	asc    bool
+	merger *merger[T]
+}
+
+func (s *funcResult[T]) Asc() bool { return s.asc }
+
+// Err returns ErrBrokenComparator if the operation was stopped because of the comparator,
+// or ErrPanic if cmp or resolve panicked
+func (s *funcResult[T]) Err() error {
+	if s.merger.err != nil {
+		return s.merger.err
+	}
+	return s.SortedNumbersStream.(Errorable).Err()
+}
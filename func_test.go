@@ -0,0 +1,82 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type record struct {
+	id      int
+	payload string
+}
+
+func compareRecords(a, b record) int { return a.id - b.id }
+
+// recordStream is a stream of records sorted by id
+type recordStream struct {
+	records []record
+}
+
+func (s *recordStream) Next() (item record, ok bool) {
+	if len(s.records) == 0 {
+		return
+	}
+	item, s.records = s.records[0], s.records[1:]
+	return item, true
+}
+
+func newRecordStream(records ...record) *recordStream { return &recordStream{records} }
+
+func TestUnionFunc(t *testing.T) {
+	older := newRecordStream(record{1, "a1"}, record{2, "a2"}, record{4, "a4"})
+	newer := newRecordStream(record{2, "b2"}, record{3, "b3"}, record{4, "b4"})
+	lastWriteWins := func(a, b record) record { return b }
+
+	result := UnionFunc[record](older, newer, true, compareRecords, lastWriteWins)
+	require.EqualValues(t, []record{{1, "a1"}, {2, "b2"}, {3, "b3"}, {4, "b4"}}, ToSlice(result))
+}
+
+func TestUnionFuncKeepsLeftByDefault(t *testing.T) {
+	a := newRecordStream(record{3, "a3"}, record{1, "a1"})
+	b := newRecordStream(record{3, "b3"}, record{2, "b2"})
+
+	result := UnionFunc[record](a, b, false, compareRecords, nil)
+	require.EqualValues(t, []record{{3, "a3"}, {2, "b2"}, {1, "a1"}}, ToSlice(result))
+}
+
+func TestUnionFuncMergesPayloads(t *testing.T) {
+	a := newRecordStream(record{1, "x"})
+	b := newRecordStream(record{1, "y"})
+	merge := func(a, b record) record { return record{a.id, a.payload + b.payload} }
+
+	result := UnionFunc[record](a, b, true, compareRecords, merge)
+	require.EqualValues(t, []record{{1, "xy"}}, ToSlice(result))
+}
+
+func TestUnionFuncBrokenComparator(t *testing.T) {
+	a := newRecordStream(record{1, "a1"}, record{2, "a2"})
+	b := newRecordStream(record{1, "b1"}, record{3, "b3"})
+	alwaysGreater := func(a, b record) int { return 1 }
+
+	result := UnionFunc[record](a, b, true, alwaysGreater, nil)
+	require.EqualValues(t, []record{}, ToSlice(result)) // stopped on the first comparison, no hang
+	require.ErrorIs(t, result.(Errorable).Err(), ErrBrokenComparator)
+
+	result = UnionFunc[record](newRecordStream(record{1, "a1"}), newRecordStream(record{2, "b2"}), true, compareRecords, nil)
+	require.Len(t, ToSlice(result), 2)
+	require.NoError(t, result.(Errorable).Err())
+	require.True(t, result.(DirectedStream[record]).Asc())
+}
+
+func TestIntersectAndDiffFunc(t *testing.T) {
+	a := func() SortedNumbersStream[record] {
+		return newRecordStream(record{1, "a1"}, record{2, "a2"}, record{2, "a2'"}, record{4, "a4"})
+	}
+	b := func() SortedNumbersStream[record] {
+		return newRecordStream(record{2, "b2"}, record{3, "b3"}, record{4, "b4"})
+	}
+
+	require.EqualValues(t, []record{{2, "a2"}, {4, "a4"}}, ToSlice(IntersectFunc(a(), b(), true, compareRecords)))
+	require.EqualValues(t, []record{{1, "a1"}}, ToSlice(DiffFunc(a(), b(), true, compareRecords)))
+}
@@ -0,0 +1,136 @@
+package sorted_numeric_streams
+
+import (
+	"container/heap"
+
+	"golang.org/x/exp/constraints"
+)
+
+// orderedHeap implements heap.Interface, the top is the smallest item for asc and the largest for desc
+type orderedHeap[T constraints.Ordered] struct {
+	items []T
+	asc   bool
+}
+
+func (h *orderedHeap[T]) Len() int { return len(h.items) }
+func (h *orderedHeap[T]) Less(i, j int) bool {
+	if h.asc {
+		return compareOrdered(h.items[i], h.items[j]) < 0
+	}
+	return compareOrdered(h.items[i], h.items[j]) > 0
+}
+func (h *orderedHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *orderedHeap[T]) Push(x any)    { h.items = append(h.items, x.(T)) }
+func (h *orderedHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// HeapStream pops items of an unsorted slice in sorted order lazily,
+// so consuming only a prefix costs O(n + k*log(n)) instead of a full sort
+type HeapStream[T constraints.Ordered] struct {
+	heap *orderedHeap[T]
+}
+
+func (s *HeapStream[T]) Next() (item T, ok bool) {
+	if s.heap.Len() == 0 {
+		return
+	}
+	return heap.Pop(s.heap).(T), true
+}
+
+// Len returns the number of remaining items
+func (s *HeapStream[T]) Len() (int, bool) { return s.heap.Len(), true }
+
+func (s *HeapStream[T]) Asc() bool { return s.heap.asc }
+
+// NewHeapStream returns the stream of items in asc or desc order
+// items is used as the heap storage, so it is reordered in place
+func NewHeapStream[T constraints.Ordered](items []T, asc bool) *HeapStream[T] {
+	h := &orderedHeap[T]{items: items, asc: asc}
+	heap.Init(h)
+	return &HeapStream[T]{h}
+}
+
+// SortWindow repairs a nearly sorted stream where every item is at most k positions away from its sorted position,
+// by buffering k+1 items in a heap. Disorder beyond k is not corrected: the output is then not sorted either.
+func SortWindow[T constraints.Ordered](stream SortedNumbersStream[T], k int, asc bool) SortedNumbersStream[T] {
+	if k < 0 {
+		panic("sort window must not be negative")
+	}
+	return &sortWindowStream[T]{
+		source: orEmpty(stream),
+		heap:   &orderedHeap[T]{items: make([]T, 0, k+1), asc: asc},
+		k:      k,
+	}
+}
+
+type sortWindowStream[T constraints.Ordered] struct {
+	source  SortedNumbersStream[T]
+	heap    *orderedHeap[T]
+	k       int
+	drained bool
+}
+
+func (s *sortWindowStream[T]) Next() (item T, ok bool) {
+	for !s.drained && s.heap.Len() <= s.k {
+		if item, ok = s.source.Next(); !ok {
+			s.drained = true
+			break
+		}
+		heap.Push(s.heap, item)
+	}
+	if s.heap.Len() == 0 {
+		var empty T
+		return empty, false
+	}
+	return heap.Pop(s.heap).(T), true
+}
+
+func (s *sortWindowStream[T]) Asc() bool { return s.heap.asc }
+
+// TopK returns the k largest items of the stream, largest first, asc is the direction of the stream.
+// A desc stream starts with them, so only its prefix is read. An asc stream is read to the end,
+// keeping the k largest items seen so far in a heap, so memory is bounded by k for unbounded streams too
+func TopK[T constraints.Ordered](stream SortedNumbersStream[T], k int, asc bool) []T {
+	stream = orEmpty(stream)
+	mustMatchDirection(asc, stream)
+	if k <= 0 {
+		return []T{}
+	}
+	size := 0 // a short stream or an unknown size does not preallocate k items, the slice grows as needed
+	if n, known := sizeOf(stream); known {
+		size = min(k, n)
+	}
+	if !asc {
+		top := make([]T, 0, size)
+		for len(top) < k {
+			item, ok := stream.Next()
+			if !ok {
+				break
+			}
+			top = append(top, item)
+		}
+		return top
+	}
+
+	h := &orderedHeap[T]{items: make([]T, 0, size), asc: true} // the top is the smallest of the kept items
+	for {
+		item, ok := stream.Next()
+		if !ok {
+			break
+		}
+		if h.Len() < k {
+			heap.Push(h, item)
+		} else if compareOrdered(item, h.items[0]) > 0 {
+			h.items[0] = item
+			heap.Fix(h, 0)
+		}
+	}
+	top := make([]T, h.Len())
+	for i := len(top) - 1; i >= 0; i-- {
+		top[i] = heap.Pop(h).(T)
+	}
+	return top
+}
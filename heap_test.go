@@ -0,0 +1,99 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeapStream(t *testing.T) {
+	require.EqualValues(t, []int{}, ToSlice[int](NewHeapStream([]int{}, true)))
+	require.EqualValues(t, []int{1, 2, 3, 3, 5}, ToSlice[int](NewHeapStream([]int{3, 1, 5, 3, 2}, true)))
+	require.EqualValues(t, []int{5, 3, 3, 2, 1}, ToSlice[int](NewHeapStream([]int{3, 1, 5, 3, 2}, false)))
+
+	items := rand.New(rand.NewSource(1)).Perm(1000)
+	expected := append([]int{}, items...)
+	sort.Ints(expected)
+	s := NewHeapStream(items, true)
+	n, _ := s.Len()
+	require.Equal(t, 1000, n)
+	require.EqualValues(t, expected, ToSlice[int](s))
+}
+
+func TestHeapStreamOperations(t *testing.T) {
+	a := NewHeapStream([]int{9, 1, 5, 3}, false)
+	b := NewSliceStream([]int{5, 4, 3})
+	require.EqualValues(t, []int{5, 3}, ToSlice(Intersect[int](a, b, false)))
+
+	require.Panics(t, func() { Union[int](NewHeapStream([]int{1}, false), b, true) })
+}
+
+func TestSortWindow(t *testing.T) {
+	type test struct {
+		items  []int
+		k      int
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{[]int{}, 2, true, []int{}},
+		{[]int{1, 2, 3}, 0, true, []int{1, 2, 3}},
+		{[]int{2, 1, 4, 3, 6, 5}, 1, true, []int{1, 2, 3, 4, 5, 6}},
+		{[]int{3, 1, 2, 6, 4, 5}, 2, true, []int{1, 2, 3, 4, 5, 6}},
+		{[]int{5, 6, 3, 4, 1, 2}, 2, false, []int{6, 5, 4, 3, 2, 1}},
+		{[]int{2, 3, 4, 1}, 1, true, []int{2, 3, 1, 4}}, // 1 is 3 positions away, beyond the window
+		{[]int{2, 3, 4, 1}, 3, true, []int{1, 2, 3, 4}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(SortWindow[int](NewSliceStream(tt.items), tt.k, tt.asc)))
+		})
+	}
+
+	require.Panics(t, func() { SortWindow[int](NewSliceStream([]int{}), -1, true) })
+}
+
+func TestTopK(t *testing.T) {
+	type test struct {
+		items  []int
+		k      int
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 4, 5}, 2, true, []int{5, 4}},
+		{[]int{1, 2, 2, 3}, 3, true, []int{3, 2, 2}},
+		{[]int{5, 4, 3, 2, 1}, 2, false, []int{5, 4}},
+		{[]int{1, 2}, 5, true, []int{2, 1}},
+		{[]int{2, 1}, 5, false, []int{2, 1}},
+		{[]int{1, 2}, 0, true, []int{}},
+		{[]int{}, 3, true, []int{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, TopK[int](NewSliceStream(tt.items), tt.k, tt.asc))
+		})
+	}
+}
+
+func TestTopKReadsPrefixOfDescStream(t *testing.T) {
+	stream, history := Record[int](NewSliceStream([]int{9, 8, 7, 6, 5}))
+	require.EqualValues(t, []int{9, 8}, TopK[int](stream, 2, false))
+	require.Len(t, history(), 2)
+}
+
+func TestTopKHugeK(t *testing.T) {
+	require.EqualValues(t, []int{3, 2}, TopK[int](NewSliceStream([]int{1, 2, 3}), 2, true))
+	require.EqualValues(t, []int{3, 2, 1}, TopK[int](NewSliceStream([]int{1, 2, 3}), math.MaxInt, true))
+	require.EqualValues(t, []int{3, 1}, TopK[int](linearStream[int]{NewSliceStream([]int{3, 1})}, math.MaxInt, false)) // the size is unknown
+}
+
+func TestTopKNaN(t *testing.T) {
+	require.EqualValues(t, []float64{2, 1}, TopK[float64](NewSliceStream([]float64{math.NaN(), 1, 2}), 2, true))
+}
@@ -0,0 +1,65 @@
+package sorted_numeric_streams
+
+// Int variants work on in-memory sorted []int directly, without the stream interface, channels or generics,
+// for the common case of two sorted int slices. Results match Union, Intersect and Diff of slice streams
+
+// UnionInts returns items present in either slice
+func UnionInts(a, b []int, asc bool) []int {
+	result := make([]int, 0, max(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		case goesBefore(a[i], b[j], asc):
+			result = append(result, a[i])
+			i++
+		default:
+			result = append(result, b[j])
+			j++
+		}
+	}
+	result = append(result, a[i:]...)
+	return append(result, b[j:]...)
+}
+
+// IntersectInts returns items present in both slices
+func IntersectInts(a, b []int, asc bool) []int {
+	result := make([]int, 0, min(len(a), len(b)))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		case goesBefore(a[i], b[j], asc):
+			i++
+		default:
+			j++
+		}
+	}
+	return result
+}
+
+// DiffInts returns items of a absent from b, every occurrence of an item found in b is removed (see Diff)
+func DiffInts(a, b []int, asc bool) []int {
+	result := make([]int, 0, len(a))
+	i, j := 0, 0
+	for i < len(a) {
+		switch {
+		case j == len(b) || goesBefore(a[i], b[j], asc):
+			result = append(result, a[i])
+			i++
+		case a[i] == b[j]:
+			removed := a[i]
+			for i < len(a) && a[i] == removed {
+				i++
+			}
+			j++
+		default:
+			j++
+		}
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntsMatchStreams(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		a, b := make([]int, r.Intn(20)), make([]int, r.Intn(20))
+		for j := range a {
+			a[j] = r.Intn(15)
+		}
+		for j := range b {
+			b[j] = r.Intn(15)
+		}
+		asc := i%2 == 0
+		order := func(items []int) {
+			sort.Slice(items, func(x, y int) bool { return goesBefore(items[x], items[y], asc) })
+		}
+		order(a)
+		order(b)
+
+		require.EqualValues(t, ToSlice(Union[int](NewSliceStream(a), NewSliceStream(b), asc)), UnionInts(a, b, asc))
+		require.EqualValues(t, ToSlice(Intersect[int](NewSliceStream(a), NewSliceStream(b), asc)), IntersectInts(a, b, asc))
+		require.EqualValues(t, ToSlice(Diff[int](NewSliceStream(a), NewSliceStream(b), asc)), DiffInts(a, b, asc))
+	}
+}
+
+func TestInts(t *testing.T) {
+	require.EqualValues(t, []int{1, 2, 3}, UnionInts([]int{1, 3}, []int{2, 3}, true))
+	require.EqualValues(t, []int{3}, IntersectInts([]int{1, 3}, []int{2, 3}, true))
+	require.EqualValues(t, []int{2}, DiffInts([]int{1, 1, 2}, []int{1}, true))
+	require.EqualValues(t, []int{}, IntersectInts(nil, []int{1}, true))
+}
+
+// BenchmarkInts compares the int variants with the generic stream operations to show the interface and generics overhead
+func BenchmarkInts(b *testing.B) {
+	s1, s2 := benchmarkOperands("half", true)
+	ops := []struct {
+		name    string
+		ints    func(a, b []int, asc bool) []int
+		streams func(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int]
+	}{
+		{"union", UnionInts, Union[int]},
+		{"intersect", IntersectInts, Intersect[int]},
+		{"diff", DiffInts, Diff[int]},
+	}
+	for _, op := range ops {
+		b.Run(fmt.Sprintf("%s ints", op.name), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				op.ints(s1, s2, true)
+			}
+		})
+		b.Run(fmt.Sprintf("%s pull streams", op.name), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToSlice(op.streams(NewSliceStream(s1), NewSliceStream(s2), true, WithPullBackend()))
+			}
+		})
+	}
+}
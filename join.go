@@ -0,0 +1,202 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// InnerJoin is a sort-merge join: it emits pairs (recA, recB) of records with equal keys,
+// both streams must be sorted by key in the given direction.
+// When several records share a key, the cross product of them is emitted, ordered by A then by B.
+// Only the current key group of B is buffered, the join is pull-based and uses no goroutines
+func InnerJoin[T any, K constraints.Ordered](a, b SortedNumbersStream[T], key func(T) K, asc bool) SortedNumbersStream[[2]T] {
+	return InnerJoinFunc(a, b, byKey(key), asc)
+}
+
+// InnerJoinFunc is InnerJoin of streams ordered by cmp, records are joined when cmp returns 0.
+// It joins on composite keys, e.g. for records sorted by (day, user):
+//
+//	byDayUser := func(a, b visit) int {
+//		if c := cmp.Compare(a.day, b.day); c != 0 {
+//			return c
+//		}
+//		return cmp.Compare(a.user, b.user)
+//	}
+func InnerJoinFunc[T any](a, b SortedNumbersStream[T], cmp func(a, b T) int, asc bool) SortedNumbersStream[[2]T] {
+	a, b = orEmpty(a), orEmpty(b)
+	mustMatchDirection(asc, a, b)
+	return &directedStream[[2]T]{&innerJoinStream[T]{a: a, groups: newKeyGroups(b, cmp, asc)}, asc}
+}
+
+// byKey orders records by the key
+func byKey[T any, K constraints.Ordered](key func(T) K) func(a, b T) int {
+	return func(a, b T) int { return compareOrdered(key(a), key(b)) }
+}
+
+type innerJoinStream[T any] struct {
+	a       SortedNumbersStream[T]
+	groups  *keyGroups[T]
+	current T   // A record being paired with the matching B group
+	pending []T // B records not yet paired with current
+	drained bool
+}
+
+func (s *innerJoinStream[T]) Next() (pair [2]T, ok bool) {
+	for len(s.pending) == 0 {
+		if s.drained {
+			return pair, false
+		}
+		item, ok := s.a.Next()
+		if !ok {
+			return pair, false
+		}
+		match, more := s.groups.seek(item)
+		if !more {
+			s.drained = true // nothing left to match in B, so A is not read any further
+			return pair, false
+		}
+		s.current, s.pending = item, match
+	}
+	pair = [2]T{s.current, s.pending[0]}
+	s.pending = s.pending[1:]
+	return pair, true
+}
+
+// keyGroups reads a stream sorted by key as groups of records sharing a key
+type keyGroups[T any] struct {
+	stream  SortedNumbersStream[T]
+	cmp     func(a, b T) int // compares keys of records, direction-aware
+	group   []T
+	head    T // first record of the next group
+	hasHead bool
+	drained bool
+}
+
+func newKeyGroups[T any](stream SortedNumbersStream[T], cmp func(a, b T) int, asc bool) *keyGroups[T] {
+	if !asc {
+		ascCmp := cmp
+		cmp = func(a, b T) int { return ascCmp(b, a) }
+	}
+	return &keyGroups[T]{stream: stream, cmp: cmp}
+}
+
+// seek skips groups with keys before the key of the record, it returns the group matching it (nil if there is none)
+// more is false once all groups are before it, so no later key can match either
+func (g *keyGroups[T]) seek(record T) (match []T, more bool) {
+	for len(g.group) == 0 || g.cmp(g.group[0], record) < 0 {
+		if !g.load() {
+			return nil, false
+		}
+	}
+	if g.cmp(g.group[0], record) == 0 {
+		return g.group, true
+	}
+	return nil, true
+}
+
+// load reads the next group, the previous group buffer is reused
+func (g *keyGroups[T]) load() bool {
+	g.group = g.group[:0]
+	if !g.hasHead {
+		if g.drained {
+			return false
+		}
+		if g.head, g.hasHead = g.stream.Next(); !g.hasHead {
+			g.drained = true
+			return false
+		}
+	}
+	first := g.head
+	for g.hasHead && g.cmp(g.head, first) == 0 {
+		g.group = append(g.group, g.head)
+		g.head, g.hasHead = g.stream.Next()
+	}
+	g.drained = !g.hasHead
+	return true
+}
+
+// LeftJoinRow is a record of A with the matching record of B, Right is nil when no B record has the same key
+type LeftJoinRow[T any] struct {
+	Left  T
+	Right *T
+}
+
+// Matched tells if a B record with the same key was found
+func (r LeftJoinRow[T]) Matched() bool { return r.Right != nil }
+
+// LeftJoin is a sort-merge left outer join: every record of A is emitted once per matching B record
+// (see InnerJoin), or once with a nil Right when there is no match
+func LeftJoin[T any, K constraints.Ordered](a, b SortedNumbersStream[T], key func(T) K, asc bool) SortedNumbersStream[LeftJoinRow[T]] {
+	return LeftJoinFunc(a, b, byKey(key), asc)
+}
+
+// LeftJoinFunc is LeftJoin of streams ordered by cmp, e.g. on composite keys (see InnerJoinFunc)
+func LeftJoinFunc[T any](a, b SortedNumbersStream[T], cmp func(a, b T) int, asc bool) SortedNumbersStream[LeftJoinRow[T]] {
+	a, b = orEmpty(a), orEmpty(b)
+	mustMatchDirection(asc, a, b)
+	return &directedStream[LeftJoinRow[T]]{&leftJoinStream[T]{a: a, groups: newKeyGroups(b, cmp, asc)}, asc}
+}
+
+type leftJoinStream[T any] struct {
+	a       SortedNumbersStream[T]
+	groups  *keyGroups[T]
+	current T
+	pending []T
+}
+
+func (s *leftJoinStream[T]) Next() (row LeftJoinRow[T], ok bool) {
+	if len(s.pending) == 0 {
+		item, ok := s.a.Next()
+		if !ok {
+			return row, false
+		}
+		match, _ := s.groups.seek(item)
+		if len(match) == 0 {
+			return LeftJoinRow[T]{Left: item}, true
+		}
+		s.current, s.pending = item, match
+	}
+	right := s.pending[0] // copy, the group buffer is reused
+	s.pending = s.pending[1:]
+	return LeftJoinRow[T]{Left: s.current, Right: &right}, true
+}
+
+// IntersectAcross is a semi-join of streams of different types: it emits the items of a whose key is also a key of
+// an item of b, e.g. IDs found in a stream of records. Both streams must be sorted by key in the given direction,
+// repeated items of a are all emitted. The join is pull-based and stops once b is drained
+func IntersectAcross[A, B any, K constraints.Ordered](a SortedNumbersStream[A], b SortedNumbersStream[B], keyA func(A) K, keyB func(B) K, asc bool) SortedNumbersStream[A] {
+	a, b = orEmpty(a), orEmpty(b)
+	mustMatchDirection(asc, a)
+	mustMatchDirection(asc, b)
+	return &directedStream[A]{&semiJoinStream[A, B, K]{a: a, b: b, keyA: keyA, keyB: keyB, asc: asc}, asc}
+}
+
+type semiJoinStream[A, B any, K constraints.Ordered] struct {
+	a       SortedNumbersStream[A]
+	b       SortedNumbersStream[B]
+	keyA    func(A) K
+	keyB    func(B) K
+	asc     bool
+	bKey    K // key of the last item read from b
+	hasB    bool
+	drained bool
+}
+
+func (s *semiJoinStream[A, B, K]) Next() (item A, ok bool) {
+	for !s.drained {
+		if item, ok = s.a.Next(); !ok {
+			return
+		}
+		key := s.keyA(item)
+		for !s.hasB || goesBefore(s.bKey, key, s.asc) {
+			next, more := s.b.Next()
+			if !more {
+				s.drained = true
+				var zero A
+				return zero, false
+			}
+			s.bKey, s.hasB = s.keyB(next), true
+		}
+		if compareOrdered(s.bKey, key) == 0 {
+			return item, true
+		}
+	}
+	return
+}
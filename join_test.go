@@ -0,0 +1,215 @@
+package sorted_numeric_streams
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func recordID(r record) int { return r.id }
+
+func TestInnerJoin(t *testing.T) {
+	type test struct {
+		name   string
+		a, b   []record
+		result [][2]record
+	}
+	tests := []test{
+		{
+			name: "unique keys",
+			a:    []record{{1, "a1"}, {2, "a2"}, {4, "a4"}},
+			b:    []record{{2, "b2"}, {3, "b3"}, {4, "b4"}},
+			result: [][2]record{
+				{{2, "a2"}, {2, "b2"}},
+				{{4, "a4"}, {4, "b4"}},
+			},
+		},
+		{
+			name: "duplicate keys on both sides",
+			a:    []record{{1, "a1"}, {2, "a2x"}, {2, "a2y"}, {3, "a3"}},
+			b:    []record{{2, "b2x"}, {2, "b2y"}, {2, "b2z"}, {3, "b3"}, {5, "b5"}},
+			result: [][2]record{
+				{{2, "a2x"}, {2, "b2x"}},
+				{{2, "a2x"}, {2, "b2y"}},
+				{{2, "a2x"}, {2, "b2z"}},
+				{{2, "a2y"}, {2, "b2x"}},
+				{{2, "a2y"}, {2, "b2y"}},
+				{{2, "a2y"}, {2, "b2z"}},
+				{{3, "a3"}, {3, "b3"}},
+			},
+		},
+		{
+			name:   "no matches",
+			a:      []record{{1, "a1"}, {3, "a3"}},
+			b:      []record{{2, "b2"}, {4, "b4"}},
+			result: [][2]record{},
+		},
+		{
+			name:   "empty",
+			a:      []record{{1, "a1"}},
+			b:      []record{},
+			result: [][2]record{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InnerJoin[record, int](newRecordStream(tt.a...), newRecordStream(tt.b...), recordID, true)
+			require.EqualValues(t, tt.result, ToSlice(result))
+		})
+	}
+}
+
+func TestInnerJoinDesc(t *testing.T) {
+	a := newRecordStream(record{3, "a3"}, record{2, "a2"}, record{1, "a1"})
+	b := newRecordStream(record{3, "b3"}, record{1, "b1x"}, record{1, "b1y"})
+	result := InnerJoin[record, int](a, b, recordID, false)
+	require.False(t, result.(DirectedStream[[2]record]).Asc())
+	require.EqualValues(t, [][2]record{
+		{{3, "a3"}, {3, "b3"}},
+		{{1, "a1"}, {1, "b1x"}},
+		{{1, "a1"}, {1, "b1y"}},
+	}, ToSlice(result))
+}
+
+func TestInnerJoinDirectionMismatch(t *testing.T) {
+	asc := WithDirection[record](newRecordStream(record{1, "a1"}), true)
+	require.Panics(t, func() { InnerJoin[record, int](asc, newRecordStream(), recordID, false) })
+	require.Panics(t, func() { InnerJoin[record, int](newRecordStream(), asc, recordID, false) })
+}
+
+func TestInnerJoinStopsEarly(t *testing.T) {
+	a, history := Record[record](newRecordStream(record{1, "a1"}, record{5, "a5"}, record{6, "a6"}, record{7, "a7"}))
+	result := InnerJoin[record, int](a, newRecordStream(record{1, "b1"}, record{2, "b2"}), recordID, true)
+	require.EqualValues(t, [][2]record{{{1, "a1"}, {1, "b1"}}}, ToSlice(result))
+	require.Len(t, history(), 2) // B is drained once A passes its last key
+}
+
+func TestLeftJoin(t *testing.T) {
+	a := newRecordStream(record{1, "a1"}, record{2, "a2x"}, record{2, "a2y"}, record{3, "a3"}, record{6, "a6"})
+	b := newRecordStream(record{2, "b2x"}, record{2, "b2y"}, record{3, "b3"}, record{4, "b4"})
+
+	type row struct {
+		left, right string
+	}
+	var rows []row
+	for _, r := range ToSlice(LeftJoin[record, int](a, b, recordID, true)) {
+		if !r.Matched() {
+			rows = append(rows, row{r.Left.payload, ""})
+			continue
+		}
+		require.Equal(t, r.Left.id, r.Right.id)
+		rows = append(rows, row{r.Left.payload, r.Right.payload})
+	}
+
+	require.EqualValues(t, []row{
+		{"a1", ""},
+		{"a2x", "b2x"},
+		{"a2x", "b2y"},
+		{"a2y", "b2x"},
+		{"a2y", "b2y"},
+		{"a3", "b3"},
+		{"a6", ""}, // B is drained, A records survive
+	}, rows)
+}
+
+func TestLeftJoinEmptyRight(t *testing.T) {
+	result := LeftJoin[record, int](newRecordStream(record{1, "a1"}), nil, recordID, true)
+	require.True(t, result.(DirectedStream[LeftJoinRow[record]]).Asc())
+	require.EqualValues(t, []LeftJoinRow[record]{{Left: record{1, "a1"}}}, ToSlice(result))
+}
+
+func TestLeftJoinDirectionMismatch(t *testing.T) {
+	desc := WithDirection[record](newRecordStream(record{1, "a1"}), false)
+	require.Panics(t, func() { LeftJoin[record, int](desc, newRecordStream(), recordID, true) })
+	require.Panics(t, func() { LeftJoin[record, int](newRecordStream(), desc, recordID, true) })
+}
+
+// visit is a record sorted by (day, user)
+type visit struct {
+	day, user int
+	page      string
+}
+
+func byDayUser(a, b visit) int {
+	if c := cmp.Compare(a.day, b.day); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.user, b.user)
+}
+
+func TestInnerJoinFuncCompositeKey(t *testing.T) {
+	a := FromSortedSeq(slices.Values([]visit{{1, 1, "a"}, {1, 2, "b"}, {2, 1, "c"}, {2, 2, "d"}}))
+	b := FromSortedSeq(slices.Values([]visit{{1, 2, "x"}, {2, 1, "y"}, {2, 1, "z"}, {3, 1, "w"}}))
+	require.EqualValues(t, [][2]visit{
+		{{1, 2, "b"}, {1, 2, "x"}},
+		{{2, 1, "c"}, {2, 1, "y"}},
+		{{2, 1, "c"}, {2, 1, "z"}},
+	}, ToSlice(InnerJoinFunc[visit](a, b, byDayUser, true)))
+}
+
+func TestLeftJoinFuncCompositeKeyDesc(t *testing.T) {
+	a := FromSortedSeq(slices.Values([]visit{{2, 2, "d"}, {2, 1, "c"}, {1, 2, "b"}}))
+	b := FromSortedSeq(slices.Values([]visit{{2, 1, "y"}, {1, 1, "x"}}))
+	rows := ToSlice(LeftJoinFunc[visit](a, b, byDayUser, false))
+	require.Len(t, rows, 3)
+	require.False(t, rows[0].Matched())
+	require.Equal(t, visit{2, 1, "y"}, *rows[1].Right)
+	require.False(t, rows[2].Matched()) // same day, another user
+}
+
+func TestIntersectAcross(t *testing.T) {
+	type test struct {
+		name   string
+		ids    []int
+		b      []record
+		asc    bool
+		result []int
+	}
+	tests := []test{
+		{"matches", []int{1, 2, 3, 5}, []record{{2, "b2"}, {3, "b3"}, {4, "b4"}}, true, []int{2, 3}},
+		{"repeated keys", []int{1, 1, 2, 2}, []record{{1, "b1x"}, {1, "b1y"}, {2, "b2"}}, true, []int{1, 1, 2, 2}},
+		{"desc", []int{5, 3, 2}, []record{{4, "b4"}, {3, "b3"}, {2, "b2"}}, false, []int{3, 2}},
+		{"empty b", []int{1, 2}, nil, true, []int{}},
+		{"empty a", nil, []record{{1, "b1"}}, true, []int{}},
+	}
+	identity := func(id int) int { return id }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := IntersectAcross[int, record, int](NewSliceStream(tt.ids), newRecordStream(tt.b...), identity, recordID, tt.asc)
+			require.Equal(t, tt.asc, result.(DirectedStream[int]).Asc())
+			require.EqualValues(t, tt.result, ToSlice(result))
+		})
+	}
+}
+
+func TestIntersectAcrossDirectionMismatch(t *testing.T) {
+	identity := func(id int) int { return id }
+	desc := WithDirection[int](NewSliceStream([]int{2, 1}), false)
+	require.Panics(t, func() { IntersectAcross[int, record, int](desc, newRecordStream(), identity, recordID, true) })
+	descRecords := WithDirection[record](newRecordStream(record{2, "b2"}), false)
+	require.Panics(t, func() {
+		IntersectAcross[int, record, int](NewSliceStream([]int{1}), descRecords, identity, recordID, true)
+	})
+}
+
+func TestIntersectAcrossStopsEarly(t *testing.T) {
+	a, history := Record[int](NewSliceStream([]int{1, 5, 6, 7}))
+	result := IntersectAcross[int, record, int](a, newRecordStream(record{1, "b1"}, record{2, "b2"}), func(id int) int { return id }, recordID, true)
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.Len(t, history(), 2)
+}
+
+func TestIntersectAcrossNaN(t *testing.T) {
+	nan := math.NaN()
+	a := NewSliceStream([]float64{nan, 1, 2, 3})
+	b := NewSliceStream([]float64{nan, 2})
+	identity := func(v float64) float64 { return v }
+	items := ToSlice(IntersectAcross[float64, float64, float64](a, b, identity, identity, true))
+	require.Len(t, items, 2)
+	require.True(t, math.IsNaN(items[0]))
+	require.Equal(t, 2.0, items[1])
+}
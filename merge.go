@@ -0,0 +1,404 @@
+package sorted_numeric_streams
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Merge returns all items of all streams in sorted order, keeping duplicates (unlike Union)
+// It is a lazy K-way merge over a heap of stream heads: no goroutines, log(K) comparisons per item
+func Merge[T constraints.Ordered](streams []SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	mustMatchDirection(asc, streams...)
+	return &mergeStream[T]{streams: streams, heads: &headsHeap[T]{asc: asc, cmp: compareOrdered[T]}}
+}
+
+// MergeUnique splits items of supposedly disjoint streams (e.g. shards) in a single pass:
+// unique gets values found in only one stream, conflicts gets values found in several streams.
+// Both results emit every value once. Reading one result buffers values for the other one until they are read,
+// so read them together (e.g. in two goroutines, they are safe for that) or drain the one you need less afterwards
+func MergeUnique[T constraints.Ordered](streams []SortedNumbersStream[T], asc bool) (unique, conflicts SortedNumbersStream[T]) {
+	mustMatchDirection(asc, streams...)
+	s := &uniqueSplitter[T]{merged: &mergeStream[T]{streams: streams, heads: &headsHeap[T]{asc: asc, cmp: compareOrdered[T]}}}
+	return &directedStream[T]{&splitReader[T]{s, false}, asc}, &directedStream[T]{&splitReader[T]{s, true}, asc}
+}
+
+// uniqueSplitter classifies groups of equal items of the merge by the number of streams they come from
+type uniqueSplitter[T constraints.Ordered] struct {
+	mu                sync.Mutex
+	merged            *mergeStream[T]
+	head              T
+	headSource        int
+	hasHead, started  bool
+	unique, conflicts []T // classified values not read yet
+}
+
+func (s *uniqueSplitter[T]) next(conflicts bool) (item T, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := &s.unique
+	if conflicts {
+		queue = &s.conflicts
+	}
+	for len(*queue) == 0 {
+		if !s.classifyNext() {
+			return
+		}
+	}
+	item, *queue = (*queue)[0], (*queue)[1:]
+	return item, true
+}
+
+// classifyNext reads the next group of equal items, false when the merge is drained
+func (s *uniqueSplitter[T]) classifyNext() bool {
+	if !s.started {
+		s.head, s.headSource, s.hasHead = s.merged.nextWithSource()
+		s.started = true
+	}
+	if !s.hasHead {
+		return false
+	}
+	value, source, conflict := s.head, s.headSource, false
+	for {
+		s.head, s.headSource, s.hasHead = s.merged.nextWithSource()
+		if !s.hasHead || compareOrdered(s.head, value) != 0 {
+			break
+		}
+		conflict = conflict || s.headSource != source
+	}
+	if conflict {
+		s.conflicts = append(s.conflicts, value)
+	} else {
+		s.unique = append(s.unique, value)
+	}
+	return true
+}
+
+type splitReader[T constraints.Ordered] struct {
+	splitter  *uniqueSplitter[T]
+	conflicts bool
+}
+
+func (r *splitReader[T]) Next() (T, bool) { return r.splitter.next(r.conflicts) }
+
+// UnionPriority is a union of streams sorted by key where the earliest stream wins: among records with equal keys
+// only the one from the stream with the lowest index is emitted (like overrides of layered configurations).
+// One record is emitted per key, streams are merged lazily over a heap of their heads
+func UnionPriority[T any, K constraints.Ordered](streams []SortedNumbersStream[T], key func(T) K, asc bool) SortedNumbersStream[T] {
+	mustMatchDirection(asc, streams...)
+	cmp := func(a, b T) int { return compareOrdered(key(a), key(b)) }
+	merged := &mergeStream[T]{streams: streams, heads: &headsHeap[T]{asc: asc, cmp: cmp}}
+	return &priorityStream[T]{merged: merged, cmp: cmp}
+}
+
+// priorityStream emits the first record of every run of equal keys, the merge puts the earliest stream first
+type priorityStream[T any] struct {
+	merged  *mergeStream[T]
+	cmp     func(a, b T) int
+	last    T
+	started bool
+}
+
+func (s *priorityStream[T]) Next() (item T, ok bool) {
+	for {
+		if item, ok = s.merged.Next(); !ok {
+			return
+		}
+		if !s.started || s.cmp(item, s.last) != 0 {
+			s.last, s.started = item, true
+			return item, true
+		}
+	}
+}
+
+func (s *priorityStream[T]) Asc() bool { return s.merged.Asc() }
+
+// ExternalSort merges sorted runs (e.g. sorted chunks of a file that does not fit in memory)
+// and writes the globally sorted result to w in EncodeStream format. Empty runs are fine.
+func ExternalSort(inputs []SortedNumbersStream[int], w io.Writer, asc bool) error {
+	merged := Merge(inputs, asc)
+	if err := EncodeStream(merged, w); err != nil {
+		return err
+	}
+	for _, input := range inputs {
+		if e, ok := input.(Errorable); ok && e.Err() != nil {
+			return e.Err()
+		}
+	}
+	return nil
+}
+
+// SortFiles sorts items of files written by EncodeStream (in any order) into the out file in the same format.
+// It is an external sort: inputs are cut into runs taking up to memoryBudget bytes, every run is sorted in memory
+// and spilled to a temp file, then the runs are merged with Merge, so the inputs may be much larger than the memory.
+// The budget counts unsafe.Sizeof(T), so it holds for fixed-size T only: for strings just the headers are counted,
+// not the contents. On failure the run files are removed and the out file is not left half-written
+func SortFiles[T constraints.Ordered](paths []string, out string, asc bool, memoryBudget int) (err error) {
+	var zero T
+	runSize := max(memoryBudget/int(unsafe.Sizeof(zero)), 1)
+	dir, err := os.MkdirTemp("", "sort-runs-*")
+	if err != nil {
+		return err
+	}
+	var opened []*fileStream[T] // inputs and runs, closed before their files are removed
+	defer func() {
+		for _, s := range opened {
+			s.close()
+		}
+		os.RemoveAll(dir)
+	}()
+	openFile := func(path string) *fileStream[T] {
+		s := &fileStream[T]{path: path}
+		opened = append(opened, s)
+		return s
+	}
+
+	var (
+		runs []SortedNumbersStream[T]
+		run  = make([]T, 0, runSize)
+	)
+	spill := func() error {
+		if len(run) == 0 {
+			return nil
+		}
+		slices.Sort(run)
+		if !asc {
+			slices.Reverse(run)
+		}
+		path := filepath.Join(dir, fmt.Sprintf("run-%d", len(runs)))
+		if err := writeStreamFile(path, NewSliceStream(run)); err != nil {
+			return err
+		}
+		runs = append(runs, openFile(path))
+		run = run[:0]
+		return nil
+	}
+	for _, path := range paths {
+		input := openFile(path)
+		for {
+			item, ok := input.Next()
+			if !ok {
+				break
+			}
+			if run = append(run, item); len(run) == runSize {
+				if err := spill(); err != nil {
+					return err
+				}
+			}
+		}
+		if err := input.Err(); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := spill(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			os.Remove(out)
+		}
+	}()
+	if err := writeStreamFile(out, Merge(runs, asc)); err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if err := run.(Errorable).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStreamFile writes the stream to the file in EncodeStream format
+func writeStreamFile[T constraints.Ordered](path string, stream SortedNumbersStream[T]) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	return errors.Join(EncodeStream(stream, f), f.Close())
+}
+
+// DiffN removes from base every item present in any of the subtract streams (with Diff semantics for repeated items)
+// in a single pass: the subtract streams are merged over a heap of their heads, no goroutines or intermediate streams
+// are needed unlike chained Diff calls
+func DiffN[T constraints.Ordered](base SortedNumbersStream[T], subtract []SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	if len(subtract) == 0 {
+		base = orEmpty(base)
+		mustMatchDirection(asc, base)
+		return &directedStream[T]{base, asc}
+	}
+	return newPullOperation(base, Merge(subtract, asc), newDiffPick[T](), diffStop, asc)
+}
+
+// ErrOverlap is reported by ConcatStrict when the streams are not disjoint ranges following each other
+var ErrOverlap = errors.New("concatenated streams overlap")
+
+// ConcatStrict returns items of range-partitioned streams one stream after another, without comparing heads like Merge.
+// Every stream must start strictly after the last item of the previous one: an overlap would silently break
+// the sort order, so the result stops before the first offending item and its Err reports ErrOverlap.
+// An Errorable stream that fails stops the result with its error too
+func ConcatStrict[T constraints.Ordered](streams []SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	mustMatchDirection(asc, streams...)
+	return &concatStream[T]{streams: streams, asc: asc}
+}
+
+type concatStream[T constraints.Ordered] struct {
+	streams []SortedNumbersStream[T]
+	asc     bool
+	current int  // index of the stream being read
+	started bool // an item of the current stream was read
+	last    T
+	hasLast bool
+	err     error
+}
+
+func (s *concatStream[T]) Next() (item T, ok bool) {
+	for s.err == nil && s.current < len(s.streams) {
+		stream := orEmpty(s.streams[s.current])
+		if item, ok = stream.Next(); !ok {
+			if e, isErrorable := stream.(Errorable); isErrorable && e.Err() != nil {
+				s.err = e.Err()
+				break
+			}
+			s.current, s.started = s.current+1, false
+			continue
+		}
+		if !s.started && s.hasLast && !goesBefore(s.last, item, s.asc) {
+			s.err = fmt.Errorf("%w: stream %d starts with %v, the previous one ends with %v", ErrOverlap, s.current, item, s.last)
+			break
+		}
+		s.started, s.last, s.hasLast = true, item, true
+		return item, true
+	}
+	var zero T
+	return zero, false
+}
+
+// Err returns ErrOverlap or the error of a failed stream, nil if all streams were concatenated
+func (s *concatStream[T]) Err() error { return s.err }
+
+func (s *concatStream[T]) Asc() bool { return s.asc }
+
+// RoundRobin takes up to n items from every stream in turn, skipping drained streams, for a fair interleave
+// of sorted partitions (e.g. scheduling or sampling). The result is NOT sorted, unlike Merge
+func RoundRobin[T any](streams []SortedNumbersStream[T], n int) SortedNumbersStream[T] {
+	if n < 1 {
+		panic("round robin must take at least one item per stream")
+	}
+	active := make([]SortedNumbersStream[T], 0, len(streams))
+	for _, stream := range streams {
+		if stream != nil {
+			active = append(active, stream)
+		}
+	}
+	return &roundRobinStream[T]{streams: active, n: n}
+}
+
+type roundRobinStream[T any] struct {
+	streams []SortedNumbersStream[T] // not drained yet
+	n       int
+	current int // index of the stream in turn
+	taken   int // items taken from it in this turn
+}
+
+func (s *roundRobinStream[T]) Next() (item T, ok bool) {
+	for len(s.streams) > 0 {
+		if s.taken == s.n {
+			s.current, s.taken = (s.current+1)%len(s.streams), 0
+		}
+		if item, ok = s.streams[s.current].Next(); ok {
+			s.taken++
+			return item, true
+		}
+		s.streams = append(s.streams[:s.current], s.streams[s.current+1:]...)
+		s.taken = 0
+		if s.current == len(s.streams) {
+			s.current = 0
+		}
+	}
+	return item, false
+}
+
+type mergeStream[T any] struct {
+	streams []SortedNumbersStream[T]
+	heads   *headsHeap[T]
+	started bool
+}
+
+func (s *mergeStream[T]) Next() (item T, ok bool) {
+	item, _, ok = s.nextWithSource()
+	return
+}
+
+// nextWithSource also returns the index of the stream the item comes from
+func (s *mergeStream[T]) nextWithSource() (item T, source int, ok bool) {
+	if !s.started {
+		s.started = true
+		for i, stream := range s.streams {
+			if stream == nil {
+				continue
+			}
+			if head, ok := stream.Next(); ok {
+				s.heads.items = append(s.heads.items, streamHead[T]{head, i})
+			}
+		}
+		heap.Init(s.heads)
+	}
+	if s.heads.Len() == 0 {
+		return
+	}
+
+	top := &s.heads.items[0]
+	item, source = top.item, top.source
+	if next, ok := s.streams[top.source].Next(); ok {
+		top.item = next
+		heap.Fix(s.heads, 0)
+	} else {
+		heap.Pop(s.heads)
+	}
+	return item, source, true
+}
+
+func (s *mergeStream[T]) Asc() bool { return s.heads.asc }
+
+// streamHead is the current item of a stream in a K-way merge
+type streamHead[T any] struct {
+	item   T
+	source int // index of the stream
+}
+
+// headsHeap orders stream heads, equal items are ordered by stream index, so merging is stable
+type headsHeap[T any] struct {
+	items []streamHead[T]
+	asc   bool
+	cmp   func(a, b T) int
+}
+
+func (h *headsHeap[T]) Len() int { return len(h.items) }
+func (h *headsHeap[T]) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	c := h.cmp(a.item, b.item)
+	if c == 0 {
+		return a.source < b.source
+	}
+	if h.asc {
+		return c < 0
+	}
+	return c > 0
+}
+func (h *headsHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *headsHeap[T]) Push(x any)    { h.items = append(h.items, x.(streamHead[T])) }
+func (h *headsHeap[T]) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
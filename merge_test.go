@@ -0,0 +1,286 @@
+package sorted_numeric_streams
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	type test struct {
+		streams [][]int
+		asc     bool
+		result  []int
+	}
+	tests := []test{
+		{nil, true, []int{}},
+		{[][]int{{}, {}}, true, []int{}},
+		{[][]int{{1, 3}}, true, []int{1, 3}},
+		{[][]int{{1, 4}, {2, 5}, {3, 6}}, true, []int{1, 2, 3, 4, 5, 6}},
+		{[][]int{{1, 2}, {}, {1, 2, 3}}, true, []int{1, 1, 2, 2, 3}}, // duplicates are kept
+		{[][]int{{6, 3}, {5, 4}, {}}, false, []int{6, 5, 4, 3}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			streams := make([]SortedNumbersStream[int], len(tt.streams))
+			for j, items := range tt.streams {
+				streams[j] = NewSliceStream(items)
+			}
+			require.EqualValues(t, tt.result, ToSlice(Merge(streams, tt.asc)))
+		})
+	}
+}
+
+func TestExternalSort(t *testing.T) {
+	// sorted runs of a big unsorted input
+	data := rand.New(rand.NewSource(1)).Perm(10_000)
+	var runs []SortedNumbersStream[int]
+	for i := 0; i < len(data); i += 1000 {
+		run := append([]int{}, data[i:i+1000]...)
+		sort.Sort(sort.Reverse(sort.IntSlice(run)))
+		runs = append(runs, NewSliceStream(run))
+	}
+	runs = append(runs, NewSliceStream([]int{})) // empty run
+
+	var buf bytes.Buffer
+	require.NoError(t, ExternalSort(runs, &buf, false))
+
+	expected := append([]int{}, data...)
+	sort.Sort(sort.Reverse(sort.IntSlice(expected)))
+	require.EqualValues(t, expected, ToSlice[int](DecodeStream[int](&buf)))
+}
+
+func TestConcatStrict(t *testing.T) {
+	type test struct {
+		name    string
+		streams [][]int
+		asc     bool
+		result  []int
+		overlap bool
+	}
+	tests := []test{
+		{"disjoint", [][]int{{1, 2}, {5, 6}, {9}}, true, []int{1, 2, 5, 6, 9}, false},
+		{"adjacent", [][]int{{1, 2}, {3, 4}}, true, []int{1, 2, 3, 4}, false},
+		{"empty streams", [][]int{{}, {1}, {}, {2}}, true, []int{1, 2}, false},
+		{"desc", [][]int{{9, 8}, {7}, {1}}, false, []int{9, 8, 7, 1}, false},
+		{"shared boundary", [][]int{{1, 2}, {2, 3}}, true, []int{1, 2}, true},
+		{"overlap", [][]int{{1, 5}, {3, 9}}, true, []int{1, 5}, true},
+		{"overlap after empty stream", [][]int{{1, 5}, {}, {4}}, true, []int{1, 5}, true},
+		{"desc overlap", [][]int{{9, 5}, {6, 1}}, false, []int{9, 5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var streams []SortedNumbersStream[int]
+			for _, items := range tt.streams {
+				streams = append(streams, NewSliceStream(items))
+			}
+			result := ConcatStrict(streams, tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(result))
+
+			err := result.(Errorable).Err()
+			if tt.overlap {
+				require.ErrorIs(t, err, ErrOverlap)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConcatStrictNaN(t *testing.T) {
+	nan := math.NaN()
+	result := ConcatStrict([]SortedNumbersStream[float64]{NewSliceStream([]float64{nan}), NewSliceStream([]float64{1})}, true)
+	require.Equal(t, "[NaN 1]", fmt.Sprint(ToSlice(result))) // NaN goes first, like in Union
+	require.NoError(t, result.(Errorable).Err())
+
+	result = ConcatStrict([]SortedNumbersStream[float64]{NewSliceStream([]float64{1}), NewSliceStream([]float64{nan})}, true)
+	require.EqualValues(t, []float64{1}, ToSlice(result))
+	require.ErrorIs(t, result.(Errorable).Err(), ErrOverlap)
+}
+
+func TestConcatStrictReportsFailure(t *testing.T) {
+	failure := errors.New("disk failure")
+	result := ConcatStrict([]SortedNumbersStream[int]{&failingStream{[]int{1}, failure}, NewSliceStream([]int{5})}, true)
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.ErrorIs(t, result.(Errorable).Err(), failure)
+}
+
+func TestDiffN(t *testing.T) {
+	base := NewRangeStream(1, 11, 1)
+	result := DiffN[int](base, []SortedNumbersStream[int]{NewSliceStream([]int{2, 4}), NewSliceStream([]int{6, 8})}, true)
+	require.EqualValues(t, []int{1, 3, 5, 7, 9, 10}, ToSlice(result))
+
+	type test struct {
+		base     []int
+		subtract [][]int
+		asc      bool
+		result   []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3}, nil, true, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, [][]int{{}, {}}, true, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, [][]int{{2}, {2, 3}, {0}}, true, []int{1}},
+		{[]int{1, 1, 2, 2}, [][]int{{1}}, true, []int{2, 2}},
+		{[]int{}, [][]int{{1}}, true, []int{}},
+		{[]int{5, 4, 3, 2, 1}, [][]int{{4}, {3, 1}}, false, []int{5, 2}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			var subtract []SortedNumbersStream[int]
+			for _, items := range tt.subtract {
+				subtract = append(subtract, NewSliceStream(items))
+			}
+			result := DiffN[int](NewSliceStream(tt.base), subtract, tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(result))
+		})
+	}
+}
+
+func TestUnionPriority(t *testing.T) {
+	overrides := newRecordStream(record{2, "override2"}, record{5, "override5"})
+	defaults := newRecordStream(record{1, "default1"}, record{2, "default2"}, record{3, "default3"}, record{5, "default5"})
+	base := newRecordStream(record{3, "base3"}, record{4, "base4"})
+
+	result := UnionPriority([]SortedNumbersStream[record]{overrides, defaults, base}, recordID, true)
+	require.EqualValues(t, []record{
+		{1, "default1"},
+		{2, "override2"},
+		{3, "default3"},
+		{4, "base4"},
+		{5, "override5"},
+	}, ToSlice(result))
+	require.True(t, result.(DirectedStream[record]).Asc())
+}
+
+func TestUnionPriorityDesc(t *testing.T) {
+	a := newRecordStream(record{3, "a3"}, record{1, "a1"})
+	b := newRecordStream(record{3, "b3"}, record{2, "b2"}, record{1, "b1"})
+	result := UnionPriority([]SortedNumbersStream[record]{b, a}, recordID, false)
+	require.EqualValues(t, []record{{3, "b3"}, {2, "b2"}, {1, "b1"}}, ToSlice(result))
+}
+
+func TestMergeUnique(t *testing.T) {
+	shards := func() []SortedNumbersStream[int] {
+		return []SortedNumbersStream[int]{
+			NewSliceStream([]int{1, 2, 3, 3, 8}),
+			NewSliceStream([]int{3, 4, 5}),
+			NewSliceStream([]int{5, 6, 8}),
+		}
+	}
+
+	unique, conflicts := MergeUnique(shards(), true)
+	require.EqualValues(t, []int{1, 2, 4, 6}, ToSlice(unique)) // conflicts are buffered meanwhile
+	require.EqualValues(t, []int{3, 5, 8}, ToSlice(conflicts))
+
+	unique, conflicts = MergeUnique(shards(), true)
+	require.EqualValues(t, []int{3, 5, 8}, ToSlice(conflicts))
+	require.EqualValues(t, []int{1, 2, 4, 6}, ToSlice(unique))
+
+	unique, conflicts = MergeUnique([]SortedNumbersStream[int]{NewSliceStream([]int{3, 1}), NewSliceStream([]int{2})}, false)
+	require.EqualValues(t, []int{3, 2, 1}, ToSlice(unique))
+	require.EqualValues(t, []int{}, ToSlice(conflicts))
+}
+
+func TestMergeUniqueConcurrentReaders(t *testing.T) {
+	unique, conflicts := MergeUnique([]SortedNumbersStream[int]{
+		NewRangeStream(0, 10_000, 2),
+		NewRangeStream(0, 10_000, 3),
+	}, true)
+
+	var wg sync.WaitGroup
+	var u, c []int
+	wg.Add(2)
+	go func() { defer wg.Done(); u = ToSlice(unique) }()
+	go func() { defer wg.Done(); c = ToSlice(conflicts) }()
+	wg.Wait()
+
+	require.Len(t, c, 1667) // multiples of 6
+	require.Len(t, u, 5000+3334-2*1667)
+}
+
+func TestRoundRobin(t *testing.T) {
+	type test struct {
+		streams [][]int
+		n       int
+		result  []int
+	}
+	tests := []test{
+		{nil, 1, []int{}},
+		{[][]int{{}, {}}, 2, []int{}},
+		{[][]int{{1, 2, 3}}, 2, []int{1, 2, 3}},
+		{[][]int{{1, 2, 3}, {10, 20, 30}}, 1, []int{1, 10, 2, 20, 3, 30}},
+		{[][]int{{1, 2, 3, 4, 5}, {10}, {20, 21, 22}}, 2, []int{1, 2, 10, 20, 21, 3, 4, 22, 5}},
+		{[][]int{{1}, {}, {10, 11, 12, 13}}, 3, []int{1, 10, 11, 12, 13}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			streams := make([]SortedNumbersStream[int], len(tt.streams))
+			for j, items := range tt.streams {
+				streams[j] = NewSliceStream(items)
+			}
+			require.EqualValues(t, tt.result, ToSlice(RoundRobin(streams, tt.n)))
+		})
+	}
+
+	require.Panics(t, func() { RoundRobin[int](nil, 0) })
+}
+
+func TestSortFiles(t *testing.T) {
+	dir := t.TempDir()
+	data := rand.New(rand.NewSource(1)).Perm(10_000)
+	var paths []string
+	for i, part := range [][]int{data[:3000], data[3000:3000], data[3000:]} { // unsorted files, one is empty
+		path := filepath.Join(dir, fmt.Sprintf("input-%d", i))
+		require.NoError(t, writeStreamFile(path, FromSortedSeq(slices.Values(part))))
+		paths = append(paths, path)
+	}
+
+	out := filepath.Join(dir, "sorted")
+	require.NoError(t, SortFiles[int](paths, out, true, 8*1000)) // runs of 1000 items, far below the input size
+	expected := ToSlice(NewRangeStream(0, 10_000, 1))
+	require.EqualValues(t, expected, ToSlice(FileFactory[int](out)()))
+
+	require.NoError(t, SortFiles[int](paths, out, false, 8*700))
+	slices.Reverse(expected)
+	require.EqualValues(t, expected, ToSlice(FileFactory[int](out)()))
+}
+
+func TestSortFilesCleansUpOnFailure(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp) // the runs are spilled here
+	dir := t.TempDir()
+	valid, truncated := filepath.Join(dir, "valid"), filepath.Join(dir, "truncated")
+	require.NoError(t, writeStreamFile(valid, NewRangeStream(0, 1000, 1)))
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[int](NewRangeStream(0, 1000, 1), &buf))
+	require.NoError(t, os.WriteFile(truncated, buf.Bytes()[:buf.Len()/2], 0o600))
+
+	out := filepath.Join(dir, "out")
+	err := SortFiles[int]([]string{valid, truncated}, out, true, 8*100) // the valid input is spilled to runs first
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	runs, err := os.ReadDir(tmp)
+	require.NoError(t, err)
+	require.Len(t, runs, 0)
+	_, err = os.Stat(out)
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestSortFilesReportsMissingInput(t *testing.T) {
+	dir := t.TempDir()
+	err := SortFiles[int]([]string{filepath.Join(dir, "missing")}, filepath.Join(dir, "out"), true, 1024)
+	require.True(t, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,83 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// MmapStream iterates a memory-mapped file of fixed-width sorted integers without copying it into the Go heap,
+// the fast path for huge on-disk posting lists. Seek is a binary search over the mapped file.
+// Close unmaps the file, the stream must not be read afterwards
+type MmapStream struct {
+	data     []byte
+	elemSize int
+	decode   func([]byte) int
+	n, pos   int
+	unmap    func() error
+}
+
+func (s *MmapStream) Next() (item int, ok bool) {
+	if s.pos >= s.n {
+		return
+	}
+	s.pos++
+	return s.at(s.pos - 1), true
+}
+
+func (s *MmapStream) at(i int) int {
+	return s.decode(s.data[i*s.elemSize : (i+1)*s.elemSize])
+}
+
+// Len returns the number of remaining items
+func (s *MmapStream) Len() (int, bool) { return s.n - s.pos, true }
+
+// Seek skips items before target with a binary search over the remaining items
+func (s *MmapStream) Seek(target int, asc bool) {
+	s.pos += sort.Search(s.n-s.pos, func(i int) bool {
+		if asc {
+			return s.at(s.pos+i) >= target
+		}
+		return s.at(s.pos+i) <= target
+	})
+}
+
+// Close unmaps the file
+func (s *MmapStream) Close() error {
+	if s.unmap == nil {
+		return nil
+	}
+	err := s.unmap()
+	s.unmap, s.data, s.n = nil, nil, 0
+	return err
+}
+
+// NewMmapStream maps the file at path, its size must be a multiple of elemSize.
+// decode converts elemSize bytes to an item, e.g. func(b []byte) int { return int(binary.BigEndian.Uint32(b)) }
+func NewMmapStream(path string, elemSize int, decode func([]byte) int) (*MmapStream, error) {
+	if elemSize < 1 {
+		return nil, fmt.Errorf("element size must be positive, got %d", elemSize)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close() // the mapping stays valid after the file is closed
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := info.Size()
+	if size%int64(elemSize) != 0 {
+		return nil, fmt.Errorf("%s: size %d is not a multiple of the element size %d", path, size, elemSize)
+	}
+	s := &MmapStream{elemSize: elemSize, decode: decode, n: int(size) / elemSize}
+	if size == 0 {
+		return s, nil // empty files can't be mapped
+	}
+	if s.data, s.unmap, err = mapFile(f, int(size)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
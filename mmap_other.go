@@ -0,0 +1,17 @@
+//go:build !unix
+
+package sorted_numeric_streams
+
+import (
+	"io"
+	"os"
+)
+
+// mapFile reads the file into memory where mmap is not available
+func mapFile(f *os.File, size int) (data []byte, unmap func() error, err error) {
+	data = make([]byte, size)
+	if _, err = io.ReadFull(f, data); err != nil {
+		return nil, nil, err
+	}
+	return data, func() error { return nil }, nil
+}
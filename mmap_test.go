@@ -0,0 +1,64 @@
+package sorted_numeric_streams
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func decodeUint32(b []byte) int { return int(binary.BigEndian.Uint32(b)) }
+
+func writeUint32File(t *testing.T, items ...int) string {
+	data := make([]byte, 0, 4*len(items))
+	for _, item := range items {
+		data = binary.BigEndian.AppendUint32(data, uint32(item))
+	}
+	path := filepath.Join(t.TempDir(), "postings")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestMmapStream(t *testing.T) {
+	s, err := NewMmapStream(writeUint32File(t, 1, 3, 5, 7, 9), 4, decodeUint32)
+	require.NoError(t, err)
+	defer s.Close()
+
+	n, _ := s.Len()
+	require.Equal(t, 5, n)
+	result := Intersect[int](s, NewSliceStream([]int{3, 4, 9}), true)
+	require.EqualValues(t, []int{3, 9}, ToSlice(result))
+}
+
+func TestMmapStreamSeek(t *testing.T) {
+	s, err := NewMmapStream(writeUint32File(t, 1, 3, 5, 7, 9), 4, decodeUint32)
+	require.NoError(t, err)
+
+	s.Seek(4, true)
+	require.EqualValues(t, []int{5, 7, 9}, ToSlice[int](s))
+	require.NoError(t, s.Close())
+	require.NoError(t, s.Close())
+
+	desc, err := NewMmapStream(writeUint32File(t, 9, 7, 5), 4, decodeUint32)
+	require.NoError(t, err)
+	defer desc.Close()
+	desc.Seek(6, false)
+	require.EqualValues(t, []int{5}, ToSlice[int](desc))
+}
+
+func TestMmapStreamErrors(t *testing.T) {
+	_, err := NewMmapStream(filepath.Join(t.TempDir(), "missing"), 4, decodeUint32)
+	require.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "odd")
+	require.NoError(t, os.WriteFile(path, []byte{1, 2, 3}, 0o600))
+	_, err = NewMmapStream(path, 4, decodeUint32)
+	require.ErrorContains(t, err, "not a multiple")
+
+	empty, err := NewMmapStream(writeUint32File(t), 4, decodeUint32)
+	require.NoError(t, err)
+	require.EqualValues(t, []int{}, ToSlice[int](empty))
+	require.NoError(t, empty.Close())
+}
@@ -0,0 +1,23 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// Picker decides what a custom operation emits at every aligned position of its operands:
+// both a and b are set when the item is in both streams, otherwise only the one of the stream containing it.
+// It returns the item to emit or nil to skip the position. Picker may keep state, positions come in sorted order,
+// but a and b point to reused variables, so copy them to keep them
+type Picker[T constraints.Ordered] func(a, b *T) *T
+
+// Operate runs a custom set operation: it merges the operands like Union, Intersect and Diff do
+// and emits what pick returns for every position. Options are the ones of the built-in operations.
+// The operands are read to the end, since a custom operation may need every position
+func Operate[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, pick Picker[T], opts ...Option) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	return runOperation(stream1, stream2, picker[T](pick), unionStop, asc, newConfig(opts))
+}
+
+// RunOperation runs pick over sorted slices synchronously and returns the result, to unit test custom operations
+func RunOperation[T constraints.Ordered](a, b []T, asc bool, pick Picker[T]) []T {
+	return ToSlice(Operate(NewSliceStream(a), NewSliceStream(b), asc, pick, WithSliceBackend()))
+}
@@ -0,0 +1,78 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// oddExclusive emits items present in exactly one stream which are at an odd position of the aligned streams
+// (positions count from 0 and include shared items), a stateful custom operation
+func oddExclusive() Picker[int] {
+	position := -1
+	return func(a, b *int) *int {
+		position++
+		if position%2 == 0 || a != nil && b != nil {
+			return nil
+		}
+		if a != nil {
+			return a
+		}
+		return b
+	}
+}
+
+func TestOperate(t *testing.T) {
+	type test struct {
+		a, b, result []int
+		asc          bool
+	}
+	tests := []test{
+		{[]int{}, []int{}, []int{}, true},
+		{[]int{1, 2}, []int{}, []int{2}, true},
+		// positions: 1(a) 2(b) 3(both) 4(a) 5(b)
+		{[]int{1, 3, 4}, []int{2, 3, 5}, []int{2, 4}, true},
+		{[]int{4, 3, 1}, []int{5, 3, 2}, []int{4, 2}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, RunOperation(tt.a, tt.b, tt.asc, oddExclusive()))
+			// streamed with the default backend gives the same result
+			require.EqualValues(t, tt.result, ToSlice(Operate(NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc, oddExclusive())))
+		})
+	}
+}
+
+func TestOperateMatchesBuiltins(t *testing.T) {
+	symmetricDiff := func(a, b *int) *int {
+		if a != nil && b != nil {
+			return nil
+		}
+		if a != nil {
+			return a
+		}
+		return b
+	}
+	a, b := []int{1, 2, 3, 5}, []int{2, 4, 5, 6}
+	expected := ToSlice(Union[int](Diff[int](NewSliceStream(a), NewSliceStream(b), true), Diff[int](NewSliceStream(b), NewSliceStream(a), true), true))
+	require.EqualValues(t, expected, RunOperation(a, b, true, symmetricDiff))
+}
+
+func ExampleOperate() {
+	// keep items of the first stream, flagging the shared ones with a negative sign
+	flagShared := func(a, b *int) *int {
+		if a == nil {
+			return nil
+		}
+		item := *a
+		if b != nil {
+			item = -item
+		}
+		return &item
+	}
+	result := Operate[int](NewSliceStream([]int{1, 2, 3}), NewSliceStream([]int{2, 4}), true, flagShared)
+	fmt.Println(ToSlice(result))
+	// Output: [1 -2 3]
+}
@@ -1,20 +1,124 @@
 package sorted_numeric_streams
 
-import "golang.org/x/exp/constraints"
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
 
 // SortedNumbersStream allows to iterate over sorted data
 // Algorithms imply the data behind this interface is sorted
-type SortedNumbersStream[T constraints.Ordered] interface {
+// T is not constrained, so the interface fits derived streams (like Zip pairs), set operations require ordered T
+type SortedNumbersStream[T any] interface {
 	// Next return the next available item from the sorted stream
 	// ok shows if the stream is drained and no further read will give anything (like a closed channel)
 	Next() (item T, ok bool)
 }
 
+// DirectedStream is a stream that knows its sort direction
+// Results of set operations are directed, so composed operations can check that directions of operands match
+type DirectedStream[T any] interface {
+	SortedNumbersStream[T]
+	// Asc returns true for ascending streams
+	Asc() bool
+}
+
+// directedStream attaches a known direction to a stream
+type directedStream[T any] struct {
+	SortedNumbersStream[T]
+	asc bool
+}
+
+func (s *directedStream[T]) Asc() bool { return s.asc }
+
+func (s *directedStream[T]) Len() (int, bool) {
+	if sized, ok := s.SortedNumbersStream.(Sized[T]); ok {
+		return sized.Len()
+	}
+	return 0, false
+}
+
+// Err forwards the error of an Errorable stream (e.g. a panic recovered by ChannelStream)
+func (s *directedStream[T]) Err() error {
+	if e, ok := s.SortedNumbersStream.(Errorable); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// NextN forwards batches of a BatchNext stream, other streams are read item by item
+func (s *directedStream[T]) NextN(buf []T) (n int, ok bool) {
+	if b, ok := s.SortedNumbersStream.(BatchNext[T]); ok {
+		return b.NextN(buf)
+	}
+	for n < len(buf) {
+		if buf[n], ok = s.SortedNumbersStream.Next(); !ok {
+			break
+		}
+		n++
+	}
+	return n, n > 0 || len(buf) == 0
+}
+
+// WithDirection marks a source stream with its sort direction, so operations can validate it
+func WithDirection[T any](stream SortedNumbersStream[T], asc bool) DirectedStream[T] {
+	return &directedStream[T]{stream, asc}
+}
+
+// keepDirection makes the wrapper report the direction of the wrapped stream, if it is known
+func keepDirection[T any](stream, wrapper SortedNumbersStream[T]) SortedNumbersStream[T] {
+	if d, ok := stream.(DirectedStream[T]); ok {
+		return &directedStream[T]{wrapper, d.Asc()}
+	}
+	return wrapper
+}
+
+// mustMatchDirection panics if a directed operand is sorted in a different direction than the operation expects
+// mixing directions silently gives wrong results, so this is treated as a programming error
+func mustMatchDirection[T any](asc bool, streams ...SortedNumbersStream[T]) {
+	for i, stream := range streams {
+		if d, ok := stream.(DirectedStream[T]); ok && d.Asc() != asc {
+			panic(fmt.Sprintf("operand %d is sorted asc=%t, but the operation expects asc=%t", i+1, d.Asc(), asc))
+		}
+	}
+}
+
+// Sized is a stream that may know how many items are left without reading them
+// Operations use it to preallocate and to choose cheaper strategies, ok=false means the size is unknown
+type Sized[T any] interface {
+	SortedNumbersStream[T]
+	Len() (n int, ok bool)
+}
+
+// BatchNext is implemented by streams which can deliver many items per call, saving an interface call
+// (and a channel receive for ChannelStream) per item. Consumers should prefer it when available, like AppendTo does
+type BatchNext[T any] interface {
+	// NextN fills buf with up to len(buf) next items and returns their number,
+	// ok is false once the stream is drained (n is 0 then)
+	NextN(buf []T) (n int, ok bool)
+}
+
+// batchSize is the buffer size of batched consumers
+const batchSize = 256
+
+// Seekable is a stream that can skip items without reading them one by one
+type Seekable[T any] interface {
+	SortedNumbersStream[T]
+	// Seek skips items going before target, so the next item is the first one >= target (asc) or <= target (desc)
+	// asc must match the direction of the stream
+	Seek(target T, asc bool)
+}
+
 // operation represent the set operation (union, diff etc)
 // since positions of set operands matter, so do operands of this func
 // when both are present - means they are equal and found in every set
 // otherwise left or right is present reflecting left or right set of the operation (A op B)
-type operation[T constraints.Ordered] func(a, b *T)
+type operation[T any] func(a, b *T)
 
 // An operation can know that no further results will be found
 // at which case it should stop reading from streams
@@ -24,21 +128,163 @@ type operation[T constraints.Ordered] func(a, b *T)
 // Note that stopped processing does not drain streams and separate cleanup required
 type shouldStop func(aClosed, bClosed bool) bool
 
+// ErrPanic is reported by Err of an operation result when the operation goroutine panicked,
+// e.g. in a user callback or an operand, the result is closed instead of blocking the reader forever
+var ErrPanic = errors.New("operation panicked")
+
 // ChannelStream is used as a result of operation on other streams
-type ChannelStream[T constraints.Ordered] struct {
-	pipe chan T
+type ChannelStream[T any] struct {
+	pipe   chan T
+	queue  *adaptiveQueue[T] // replaces pipe with WithAdaptiveBuffer
+	err    error
+	closed sync.Once
+
+	// a cancelled operation (see WithContext) keeps the results nobody has read until a flush is set
+	mu        sync.Mutex
+	cancelled bool
+	unread    []T
+	flush     func(unread []T)
 }
 
 func (s *ChannelStream[T]) Next() (item T, ok bool) {
+	if s.queue != nil {
+		return s.queue.pop()
+	}
 	item, ok = <-s.pipe
 	return
 }
 
-func (s *ChannelStream[T]) Push(item T) { s.pipe <- item }
+// NextN waits for the first item like Next, then takes items already sent without blocking (see BatchNext)
+func (s *ChannelStream[T]) NextN(buf []T) (n int, ok bool) {
+	if len(buf) == 0 {
+		return 0, true
+	}
+	if buf[0], ok = s.Next(); !ok {
+		return 0, false
+	}
+	for n = 1; n < len(buf) && s.queue == nil; n++ { // the adaptive queue delivers items one by one
+		select {
+		case item, ok := <-s.pipe:
+			if !ok {
+				return n, true
+			}
+			buf[n] = item
+		default:
+			return n, true
+		}
+	}
+	return n, true
+}
+
+// Push blocks until the item is received, so calling it in the goroutine that reads the stream deadlocks
+func (s *ChannelStream[T]) Push(item T) {
+	if s.queue != nil {
+		s.queue.push(item)
+		return
+	}
+	s.pipe <- item
+}
+
+// pushContext is Push which gives up once ctx is done, it returns false if the item is not delivered
+func (s *ChannelStream[T]) pushContext(ctx context.Context, item T) bool {
+	if s.queue != nil {
+		return s.queue.pushContext(ctx, item)
+	}
+	select {
+	case s.pipe <- item:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// takeBuffered removes the items pushed but not read yet, only the producer may call it
+func (s *ChannelStream[T]) takeBuffered() (items []T) {
+	if s.queue != nil {
+		return s.queue.takeAll()
+	}
+	for {
+		select {
+		case item := <-s.pipe:
+			items = append(items, item)
+		default:
+			return items
+		}
+	}
+}
+
+// cancel hands over the results nobody has read to the flush (see WithPartialOnCancel), or keeps them for it
+func (s *ChannelStream[T]) cancel(unread []T) {
+	s.mu.Lock()
+	s.cancelled = true
+	flush := s.flush
+	if flush == nil {
+		s.unread = unread
+	}
+	s.mu.Unlock()
+	if flush != nil {
+		flush(unread)
+	}
+}
+
+// onCancel sets the flush, it is called right away if the operation is already cancelled
+func (s *ChannelStream[T]) onCancel(flush func(unread []T)) {
+	s.mu.Lock()
+	if !s.cancelled {
+		s.flush = flush
+		s.mu.Unlock()
+		return
+	}
+	unread := s.unread
+	s.unread = nil
+	s.mu.Unlock()
+	flush(unread)
+}
+
+// TryPush delivers the item only if it can be done without blocking, i.e. a reader is waiting in Next
+// or the channel has buffer space (see WithChannelBackend). It returns false otherwise, so a non-blocking
+// producer can keep the item and retry later:
+//
+//	for !s.TryPush(item) {
+//		doOtherWork()
+//	}
+func (s *ChannelStream[T]) TryPush(item T) bool {
+	if s.queue != nil {
+		return s.queue.tryPush(item)
+	}
+	select {
+	case s.pipe <- item:
+		return true
+	default:
+		return false
+	}
+}
+
+// Close ends the stream once buffered items are read, so Next returns false afterwards.
+// It is idempotent: the producer and the user code may both call it
+func (s *ChannelStream[T]) Close() {
+	s.closed.Do(func() {
+		if s.queue != nil {
+			s.queue.close()
+			return
+		}
+		close(s.pipe)
+	})
+}
+
+// Err returns ErrPanic if the producer goroutine panicked, once the stream is drained
+func (s *ChannelStream[T]) Err() error { return s.err }
 
-func (s *ChannelStream[T]) Close() { close(s.pipe) }
+// closeOnPanic is deferred by producer goroutines, so a panic closes the stream with ErrPanic
+// instead of crashing the program
+func (s *ChannelStream[T]) closeOnPanic() {
+	if r := recover(); r != nil {
+		s.err = fmt.Errorf("%w: %v", ErrPanic, r)
+		s.Close()
+	}
+}
 
-func NewChannelStream[T constraints.Ordered]() *ChannelStream[T] {
+func NewChannelStream[T any]() *ChannelStream[T] {
 	return &ChannelStream[T]{
 		pipe: make(chan T),
 	}
@@ -51,6 +297,10 @@ type SliceStream[T constraints.Ordered] struct {
 }
 
 func (s *SliceStream[T]) Reset() { s.pos = 0 }
+
+// Len returns the number of remaining items
+func (s *SliceStream[T]) Len() (int, bool) { return len(s.slice) - s.pos, true }
+
 func (s *SliceStream[T]) Next() (item T, ok bool) {
 	if s.pos < len(s.slice) {
 		item = s.slice[s.pos]
@@ -60,6 +310,55 @@ func (s *SliceStream[T]) Next() (item T, ok bool) {
 	var empty T // zero initialized
 	return empty, false
 }
+
+// NextN copies the next items to buf (see BatchNext)
+func (s *SliceStream[T]) NextN(buf []T) (n int, ok bool) {
+	n = copy(buf, s.slice[s.pos:])
+	s.pos += n
+	return n, n > 0 || len(buf) == 0 && s.pos < len(s.slice)
+}
+
+// Seek skips items before target with galloping (exponential) search, so sequential seeks are cheap
+func (s *SliceStream[T]) Seek(target T, asc bool) {
+	s.pos = gallop(s.slice, s.pos, target, asc)
+}
+
+// gallop returns the position of the first item in slice[from:] which does not go before target
+func gallop[T constraints.Ordered](slice []T, from int, target T, asc bool) int {
+	before := func(i int) bool {
+		if asc {
+			return compareOrdered(slice[i], target) < 0
+		}
+		return compareOrdered(slice[i], target) > 0
+	}
+
+	// gallop to find the range containing the target
+	lo, step := from, 1
+	for lo < len(slice) && before(lo) {
+		lo += step
+		step *= 2
+	}
+	start := lo - step/2 // the last probed position which went before the target
+	if start < from {
+		start = from
+	}
+	if lo > len(slice) {
+		lo = len(slice)
+	}
+	return start + sort.Search(lo-start, func(i int) bool { return !before(start + i) })
+}
+
+// drain returns the remaining items sharing the backing array,
+// capacity is limited so appending to the result never overwrites the original slice
+func (s *SliceStream[T]) drain() []T {
+	if s.pos == len(s.slice) {
+		return []T{}
+	}
+	remaining := s.slice[s.pos:len(s.slice):len(s.slice)]
+	s.pos = len(s.slice)
+	return remaining
+}
+
 func NewSliceStream[T constraints.Ordered](slice []T) *SliceStream[T] {
 	return &SliceStream[T]{
 		slice: slice,
@@ -68,66 +367,323 @@ func NewSliceStream[T constraints.Ordered](slice []T) *SliceStream[T] {
 }
 
 // Union returns the stream consisting of elements that are either in stream1 or stream2
-func Union[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
-	result := NewChannelStream[T]()
-	unionOperation := func(a, b *T) {
-		// equal: both present
-		if a != nil && b != nil {
-			result.Push(*a)
-			return
-		}
-		// only left present
-		if a != nil {
-			result.Push(*a)
-			return
+func Union[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	cfg := newConfig(opts)
+	if cfg.allowsShortcuts() && isDrained(stream1) {
+		return &directedStream[T]{stream2, asc}
+	} else if cfg.allowsShortcuts() && isDrained(stream2) {
+		return &directedStream[T]{stream1, asc}
+	}
+	return runOperation(stream1, stream2, unionPick[T], unionStop, asc, cfg)
+}
+
+// Intersect returns the stream consisting of elements that are in both stream1 and stream2
+func Intersect[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	cfg := newConfig(opts)
+	if cfg.allowsShortcuts() && (isDrained(stream1) || isDrained(stream2)) {
+		return &directedStream[T]{NewSliceStream[T](nil), asc}
+	}
+	if slice1, ok := stream1.(*SliceStream[T]); ok && cfg.allowsShortcuts() {
+		if slice2, ok := stream2.(*SliceStream[T]); ok {
+			return &directedStream[T]{NewSliceStream(intersectSlices(slice1, slice2, asc, nil)), asc}
 		}
-		// only right present
-		if b != nil {
-			result.Push(*b)
-			return
+	}
+	return runOperation(stream1, stream2, intersectPick[T], intersectStop, asc, cfg)
+}
+
+// intersectSlices intersects remaining items of slice streams in place of the merge, without goroutines:
+// it gallops over runs of items missing in the other slice, so sparse matches cost O(m*log(n/m))
+// Repeated items are matched one to one, like the merge does. Operands stop right after the last compared items.
+// Matches are appended to result
+func intersectSlices[T constraints.Ordered](stream1, stream2 *SliceStream[T], asc bool, result []T) []T {
+	a, b := stream1.slice, stream2.slice
+	i, j := stream1.pos, stream2.pos
+	for i < len(a) && j < len(b) {
+		c := compareOrdered(a[i], b[j])
+		switch {
+		case c == 0:
+			result = append(result, a[i])
+			i, j = i+1, j+1
+		case asc && c < 0 || !asc && c > 0:
+			i = gallop(a, i, b[j], asc)
+		default:
+			j = gallop(b, j, a[i], asc)
 		}
 	}
-	shouldStopDecision := func(aClosed, bClosed bool) bool { return false }
+	stream1.pos, stream2.pos = i, j
+	return result
+}
 
-	go func() {
-		iterate(stream1, stream2, unionOperation, shouldStopDecision, asc)
-		result.Close()
-	}()
+// IntersectInPlace is Intersect of two SliceStreams which writes the result over the remaining items of a
+// instead of allocating it: a match is written only after the item of a is read, so writes never overtake reads.
+// The result is a view of the backing array of a: the slice given to NewSliceStream for a is overwritten
+// (the result followed by stale items), so it must not be used afterwards, neither by the caller nor by anything
+// sharing that array (e.g. another SliceStream over it or the cached postings it came from).
+// Use it only for operands owned by the caller, like a freshly decoded posting list. Both operands are drained
+func IntersectInPlace[T constraints.Ordered](a, b *SliceStream[T], asc bool) *SliceStream[T] {
+	if a == nil || b == nil {
+		return NewSliceStream[T](nil)
+	}
+	result := intersectSlices(a, b, asc, a.slice[a.pos:a.pos])
+	a.pos, b.pos = len(a.slice), len(b.slice)
+	return NewSliceStream(result)
+}
 
-	return result
+// Diff returns the stream consisting of elements that are in stream1 but not in stream2
+// With repeated items every occurrence of an item found in stream2 is removed: [1,1,2] \ [1] gives [2],
+// use WithDiffMode(RemoveOnce) to subtract occurrences one by one instead
+func Diff[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool, opts ...Option) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	cfg := newConfig(opts)
+	if cfg.allowsShortcuts() && isDrained(stream1) {
+		return &directedStream[T]{NewSliceStream[T](nil), asc}
+	} else if cfg.allowsShortcuts() && isDrained(stream2) {
+		return &directedStream[T]{stream1, asc}
+	}
+	pick := newDiffPick[T]()
+	if cfg.diffMode == RemoveOnce {
+		pick = removeOncePick[T]
+	}
+	return runOperation(stream1, stream2, pick, diffStop, asc, cfg)
 }
 
-// Intersect returns the stream consisting of elements that are in both stream1 and stream2
-func Intersect[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
-	result := NewChannelStream[T]()
-	unionOperation := func(a, b *T) {
-		// equal: both present
+// orEmpty treats a nil operand as an empty stream, so callers building streams dynamically don't crash the operation
+func orEmpty[T any](stream SortedNumbersStream[T]) SortedNumbersStream[T] {
+	if stream == nil {
+		return emptyStream[T]{}
+	}
+	return stream
+}
+
+// emptyStream is drained from the start
+type emptyStream[T any] struct{}
+
+func (emptyStream[T]) Next() (item T, ok bool) { return }
+func (emptyStream[T]) Len() (int, bool)        { return 0, true }
+
+// isDrained detects empty operands, so operations can skip the goroutine and channel setup
+// Only Sized streams are detected: peeking other streams would block the caller
+// (e.g. a ChannelStream that the caller fills later)
+func isDrained[T constraints.Ordered](stream SortedNumbersStream[T]) bool {
+	if s, ok := stream.(Sized[T]); ok {
+		n, known := s.Len()
+		return known && n == 0
+	}
+	return false
+}
+
+// picker selects the item a set operation emits at the given position (see operation), nil means nothing
+type picker[T constraints.Ordered] func(a, b *T) *T
+
+func unionPick[T constraints.Ordered](a, b *T) *T {
+	// equal or only left present
+	if a != nil {
+		return a
+	}
+	// only right present
+	return b
+}
+
+func intersectPick[T constraints.Ordered](a, b *T) *T {
+	// equal: both present
+	if a != nil && b != nil {
+		return a
+	}
+	return nil
+}
+
+// newDiffPick removes every occurrence of an item found in stream2 (set semantics):
+// [1,1,2] \ [1] gives [2], while duplicates of items not found in stream2 are kept: [1,1,2] \ [2] gives [1,1]
+func newDiffPick[T constraints.Ordered]() picker[T] {
+	var (
+		removed    T
+		hasRemoved bool
+	)
+	return func(a, b *T) *T {
 		if a != nil && b != nil {
-			result.Push(*a)
+			removed, hasRemoved = *a, true
+			return nil
+		}
+		if a != nil && !(hasRemoved && compareOrdered(*a, removed) == 0) {
+			return a
 		}
+		return nil
 	}
-	shouldStopDecision := func(aClosed, bClosed bool) bool { return aClosed || bClosed }
+}
 
+// removeOncePick subtracts repeated items one by one (bag semantics):
+// the merge pairs equal items of both operands one to one, so only unpaired items of stream1 are kept
+func removeOncePick[T constraints.Ordered](a, b *T) *T {
+	if b == nil {
+		return a
+	}
+	return nil
+}
+
+func unionStop(aClosed, bClosed bool) bool     { return false }
+func intersectStop(aClosed, bClosed bool) bool { return aClosed || bClosed }
+func diffStop(aClosed, bClosed bool) bool      { return aClosed }
+
+// runOperation pushes picked items to the result stream from a background goroutine
+func runOperation[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], pick picker[T], stop shouldStop, asc bool, cfg *config) SortedNumbersStream[T] {
+	// failures are watched on the raw operands, the wrappers below hide Errorable
+	var failure *operandFailure
+	if cfg.errs != nil {
+		failure = &operandFailure{}
+		stream1, stream2 = watchFailure(failure, stream1), watchFailure(failure, stream2)
+		stop = failure.stopOn(stop)
+	}
+
+	var progress *progressReporter
+	if cfg.onProgress != nil {
+		progress = newProgressReporter(cfg.progressEvery, cfg.onProgress)
+		stream1 = &progressStream[T]{stream1, progress, &progress.readA}
+		stream2 = &progressStream[T]{stream2, progress, &progress.readB}
+	}
+
+	if ctx := cfg.ctx; ctx != nil {
+		stream1, stream2 = &contextStream[T]{stream1, ctx}, &contextStream[T]{stream2, ctx}
+		canStop := stop
+		stop = func(aClosed, bClosed bool) bool { return ctx.Err() != nil || canStop(aClosed, bClosed) }
+	}
+
+	// reportFailure must happen before the result is closed, so the reader finds the error once the result is drained.
+	// errs gets at most one error, a cancellation after a failure is not reported
+	reported := false
+	reportFailure := func() {
+		if failure != nil && failure.err != nil {
+			cfg.errs <- failure.err
+			reported = true
+		}
+	}
+	finish := func() {
+		if cfg.errs != nil {
+			close(cfg.errs)
+		}
+		if progress != nil {
+			progress.finish()
+		}
+	}
+
+	switch cfg.backend {
+	case sliceBackend:
+		var items []T
+		iterate(stream1, stream2, func(a, b *T) {
+			if item := pick(a, b); item != nil {
+				items = append(items, *item)
+			}
+		}, stop, asc)
+		reportFailure()
+		finish()
+		return &directedStream[T]{NewSliceStream(items), asc}
+	case pullBackend:
+		return &pullOperation[T]{
+			merger: newMerger(stream1, stream2, compareOrdered[T], stop, asc),
+			pick:   pick,
+			asc:    asc,
+			onDone: func() {
+				reportFailure()
+				finish()
+			},
+		}
+	}
+
+	result := &ChannelStream[T]{pipe: make(chan T, cfg.channelCap)}
+	if cfg.adaptiveMax > 0 {
+		result = &ChannelStream[T]{queue: newAdaptiveQueue[T](cfg.adaptiveMin, cfg.adaptiveMax)}
+	}
+	pickOperation := func(a, b *T) {
+		if item := pick(a, b); item != nil {
+			result.Push(*item)
+		}
+	}
+	var stall *stallDetector
+	if cfg.onStall != nil && cfg.stallAfter > 0 {
+		stall = newStallDetector(cfg.stallAfter, cfg.onStall)
+	}
+	var unread []T // results merged but not read when the operation is cancelled
+	if cfg.ctx != nil || stall != nil {
+		pickOperation = func(a, b *T) {
+			item := pick(a, b)
+			if item == nil {
+				return
+			}
+			if cfg.ctx == nil {
+				result.Push(*item)
+			} else if len(unread) > 0 || !result.pushContext(cfg.ctx, *item) { // keep the order once cancelled
+				unread = append(unread, *item)
+				return
+			}
+			if stall != nil {
+				stall.pushed.Add(1)
+			}
+		}
+	}
+	// cancel ends the result of a cancelled operation, handing over the results nobody has read
+	cancel := func() {
+		if cfg.ctx == nil || cfg.ctx.Err() == nil {
+			return
+		}
+		result.err = cfg.ctx.Err()
+		result.cancel(append(result.takeBuffered(), unread...))
+	}
+	stopWaking := func() bool { return false }
+	if cfg.ctx != nil && result.queue != nil {
+		stopWaking = context.AfterFunc(cfg.ctx, result.queue.wake)
+	}
 	go func() {
-		iterate(stream1, stream2, unionOperation, shouldStopDecision, asc)
+		defer stopWaking()
+		defer func() {
+			if stall != nil {
+				stall.stop()
+			}
+			if result.err != nil && cfg.errs != nil && !reported {
+				cfg.errs <- result.err
+			}
+			finish()
+		}()
+		defer result.closeOnPanic()
+		iterate(stream1, stream2, pickOperation, stop, asc)
+		reportFailure()
+		cancel()
 		result.Close()
 	}()
 
-	return result
+	return &directedStream[T]{result, asc}
 }
 
-// Diff returns the stream consisting of elements that are in stream1 but not in stream2
-func Diff[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
-	result := NewChannelStream[T]()
-	unionOperation := func(a, b *T) {
-		if a != nil && b == nil {
-			result.Push(*a)
+// Pair is a position of two aligned streams: the item is present in A, B or both
+type Pair[T constraints.Ordered] struct {
+	A, B *T
+}
+
+// Zip returns the stream of aligned positions of stream1 and stream2, one per each distinct item
+// this is the raw join structure before any set operation filters it, so custom logic can be built on top
+func Zip[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[Pair[T]] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	result := NewChannelStream[Pair[T]]()
+	zipOperation := func(a, b *T) {
+		var p Pair[T]
+		if a != nil {
+			aCopy := *a
+			p.A = &aCopy
 		}
+		if b != nil {
+			bCopy := *b
+			p.B = &bCopy
+		}
+		result.Push(p)
 	}
-	shouldStopDecision := func(aClosed, bClosed bool) bool { return aClosed }
 
 	go func() {
-		iterate(stream1, stream2, unionOperation, shouldStopDecision, asc)
+		defer result.closeOnPanic()
+		iterate(stream1, stream2, zipOperation, unionStop, asc)
 		result.Close()
 	}()
 
@@ -135,83 +691,160 @@ func Diff[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bo
 }
 
 func iterate[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], op operation[T], stop shouldStop, asc bool) {
-	var (
-		i1, i2         T
-		empty1, empty2 bool
-		readOk         bool
-	)
-	empty1, empty2 = true, true
+	newMerger(stream1, stream2, compareOrdered[T], stop, asc).run(op)
+}
 
+// merger aligns two sorted streams and returns one position at a time (in the same form operation[T] receives it)
+type merger[T any] struct {
+	stream1, stream2 SortedNumbersStream[T]
+	cmp              func(a, b T) int // negative if a < b, zero if they are equal and positive if a > b
+	stop             shouldStop
+	asc              bool
+
+	i1, i2           T
+	has1, has2       bool // an item is read and is waiting for comparison
+	closed1, closed2 bool
+	done             bool
+
+	checkCmp bool  // validate every comparison of a user comparator (see ErrBrokenComparator)
+	err      error // why the merge stopped early
+}
+
+func newMerger[T any](stream1, stream2 SortedNumbersStream[T], cmp func(a, b T) int, stop shouldStop, asc bool) *merger[T] {
+	return &merger[T]{stream1: stream1, stream2: stream2, cmp: cmp, stop: stop, asc: asc}
+}
+
+// run feeds all positions to the operation
+func (m *merger[T]) run(op operation[T]) {
 	for {
-		if empty1 {
-			i1, readOk = stream1.Next()
-			if !readOk {
-				if stop(true, false) {
-					return
-				}
-				for { // no more in stream1 -> return all from stream2
-					if !empty2 {
-						op(nil, &i2)
-					}
-					i2, readOk = stream2.Next()
-					if !readOk {
-						return
-					}
-					op(nil, &i2)
-				}
+		a, b, ok := m.next()
+		if !ok {
+			return
+		}
+		op(a, b)
+	}
+}
+
+// compareOrdered is the natural order comparison, it is a total order for floats too:
+// NaN equals NaN and goes before any other number (like cmp.Compare and slices.Sort),
+// otherwise NaN compares false to everything and the merge would emit items out of order
+func compareOrdered[T constraints.Ordered](a, b T) int {
+	return cmp.Compare(a, b)
+}
+
+// goesBefore tells if a goes before b in the given direction, ordered by compareOrdered
+func goesBefore[T constraints.Ordered](a, b T, asc bool) bool {
+	c := compareOrdered(a, b)
+	return asc && c < 0 || !asc && c > 0
+}
+
+// next returns the next position: both a and b are present when they are equal,
+// otherwise only the one which goes first in the sort order
+// pointers are valid until the following call
+func (m *merger[T]) next() (a, b *T, ok bool) {
+	if m.done {
+		return nil, nil, false
+	}
+
+	if !m.has1 && !m.closed1 {
+		if m.i1, m.has1 = m.stream1.Next(); !m.has1 {
+			m.closed1 = true
+			if m.stop(true, m.closed2) {
+				m.done = true
+				return nil, nil, false
 			}
-			empty1 = false
-		}
-
-		if empty2 {
-			i2, readOk = stream2.Next()
-			if !readOk {
-				if stop(false, true) {
-					return
-				}
-				for { // no more from stream2 -> return all from stream1
-					if !empty1 {
-						op(&i1, nil)
-						empty1 = true
-					}
-					i1, readOk = stream1.Next()
-					if !readOk {
-						return
-					}
-					op(&i1, nil)
-				}
+		}
+	}
+
+	if !m.has2 && !m.closed2 {
+		if m.i2, m.has2 = m.stream2.Next(); !m.has2 {
+			m.closed2 = true
+			if m.stop(m.closed1, true) {
+				m.done = true
+				return nil, nil, false
 			}
-			empty2 = false
 		}
+	}
 
-		// Both streams have values
-		if i1 == i2 {
-			op(&i1, &i2)
-			empty1, empty2 = true, true
-		} else if asc && i1 < i2 {
-			op(&i1, nil)
-			empty1 = true
-		} else if asc && i1 > i2 {
-			op(nil, &i2)
-			empty2 = true
-		} else if !asc && i1 < i2 {
-			op(nil, &i2)
-			empty2 = true
-		} else if !asc && i1 > i2 {
-			op(&i1, nil)
-			empty1 = true
+	switch {
+	case m.has1 && m.has2:
+		c := m.cmp(m.i1, m.i2)
+		if m.checkCmp {
+			if reverse := m.cmp(m.i2, m.i1); (c == 0) != (reverse == 0) || (c < 0) != (reverse > 0) {
+				m.err = fmt.Errorf("%w: cmp(%v, %v) = %d, but cmp(%v, %v) = %d", ErrBrokenComparator, m.i1, m.i2, c, m.i2, m.i1, reverse)
+				m.done = true
+				return nil, nil, false
+			}
 		}
+		if c == 0 {
+			m.has1, m.has2 = false, false
+			return &m.i1, &m.i2, true
+		} else if m.asc && c < 0 || !m.asc && c > 0 {
+			m.has1 = false
+			return &m.i1, nil, true
+		}
+		// c > 0 for asc or c < 0 for desc: every comparison result takes one of the branches,
+		// so the merge always advances and can't spin, whatever the comparator returns
+		m.has2 = false
+		return nil, &m.i2, true
+	case m.has1: // no more in stream2
+		m.has1 = false
+		return &m.i1, nil, true
+	case m.has2: // no more in stream1
+		m.has2 = false
+		return nil, &m.i2, true
 	}
+
+	m.done = true
+	return nil, nil, false
 }
 
-func ToSlice[T constraints.Ordered](stream SortedNumbersStream[T]) []T {
-	ret := make([]T, 0)
+// ToSlice drains the stream into a slice
+// For a SliceStream the remaining part of its backing slice is returned without copying (so it shares memory with it)
+func ToSlice[T any](stream SortedNumbersStream[T]) []T {
+	capHint := 0
+	if s, ok := stream.(Sized[T]); ok {
+		capHint, _ = s.Len()
+	}
+	return ToSliceCap(stream, capHint)
+}
+
+// ToSliceCap is ToSlice preallocating capHint items, so callers knowing the cardinality avoid reallocations.
+// The result has a capacity of at least capHint: the backing slice of a SliceStream is returned without copying
+// only when it is large enough, otherwise it is copied
+func ToSliceCap[T any](stream SortedNumbersStream[T], capHint int) []T {
+	if s, ok := stream.(interface{ drain() []T }); ok {
+		items := s.drain()
+		if cap(items) >= capHint {
+			return items
+		}
+		return append(make([]T, 0, capHint), items...)
+	}
+	return AppendTo(make([]T, 0, capHint), stream)
+}
+
+// AppendTo drains the stream appending items to dst and returns the extended slice (like append does)
+// Reusing dst across queries (e.g. from a sync.Pool) reduces allocations
+func AppendTo[T any](dst []T, stream SortedNumbersStream[T]) []T {
+	if s, ok := stream.(interface{ drain() []T }); ok {
+		return append(dst, s.drain()...)
+	}
+	if s, ok := stream.(BatchNext[T]); ok {
+		var buf [batchSize]T
+		for {
+			n, ok := s.NextN(buf[:])
+			if !ok {
+				return dst
+			}
+			dst = append(dst, buf[:n]...)
+		}
+	}
 	for {
 		i, ok := stream.Next()
 		if !ok {
 			break
 		}
-		ret = append(ret, i)
+		dst = append(dst, i)
 	}
-	return ret
+	return dst
 }
@@ -1,8 +1,13 @@
 package sorted_numeric_streams
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"math/rand"
+	"runtime"
+	"slices"
+	"sort"
 	"testing"
 )
 
@@ -60,7 +65,7 @@ func TestEarlyFinish(t *testing.T) {
 			asc:                     false,
 			op:                      "intersect",
 			expectedResult:          []int{3},
-			expectedRemainingItemsA: []int{1},
+			expectedRemainingItemsA: []int{2, 1}, // slice operands are intersected without reading ahead
 			expectedRemainingItemsB: []int{},
 		},
 		{
@@ -87,7 +92,16 @@ func TestEarlyFinish(t *testing.T) {
 			asc:                     true,
 			op:                      "intersect",
 			expectedResult:          []int{1},
-			expectedRemainingItemsA: []int{3}, // "2" is read anyways and "wasted"
+			expectedRemainingItemsA: []int{2, 3}, // slice operands are intersected without reading ahead
+			expectedRemainingItemsB: []int{},
+		},
+		{
+			a:                       linearStream[int]{NewSliceStream([]int{1, 2, 3})},
+			b:                       linearStream[int]{NewSliceStream([]int{1})},
+			asc:                     true,
+			op:                      "intersect",
+			expectedResult:          []int{1},
+			expectedRemainingItemsA: []int{3}, // "2" is read anyways and "wasted" by the merge
 			expectedRemainingItemsB: []int{},
 		},
 	}
@@ -109,12 +123,116 @@ func TestEarlyFinish(t *testing.T) {
 	}
 }
 
+func TestIntersectSlices(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a, b := make([]int, r.Intn(50)), make([]int, r.Intn(500))
+		for j := range a {
+			a[j] = r.Intn(100)
+		}
+		for j := range b {
+			b[j] = r.Intn(100)
+		}
+		sort.Ints(a)
+		sort.Ints(b)
+
+		fast := Intersect[int](NewSliceStream(a), NewSliceStream(b), true)
+		merged := Intersect[int](linearStream[int]{NewSliceStream(a)}, linearStream[int]{NewSliceStream(b)}, true)
+		require.EqualValues(t, ToSlice(merged), ToSlice(fast))
+	}
+
+	result := Intersect[int](NewSliceStream([]int{5, 3, 3, 1}), NewSliceStream([]int{4, 3, 3, 3, 1, 0}), false)
+	require.EqualValues(t, []int{3, 3, 1}, ToSlice(result))
+	require.False(t, result.(DirectedStream[int]).Asc())
+}
+
+func TestIntersectInPlace(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		a, b := make([]int, r.Intn(50)), make([]int, r.Intn(500))
+		for j := range a {
+			a[j] = r.Intn(100)
+		}
+		for j := range b {
+			b[j] = r.Intn(100)
+		}
+		sort.Ints(a)
+		sort.Ints(b)
+		expected := ToSlice(Intersect[int](NewSliceStream(slices.Clone(a)), NewSliceStream(b), true))
+
+		result := IntersectInPlace(NewSliceStream(a), NewSliceStream(b), true)
+		require.EqualValues(t, expected, ToSlice[int](result))
+	}
+
+	backing := []int{9, 7, 5, 3, 1}
+	a := NewSliceStream(backing)
+	a.Next()
+	result := IntersectInPlace(a, NewSliceStream([]int{8, 5, 1}), false)
+	require.EqualValues(t, []int{5, 1}, ToSlice[int](result))
+	require.EqualValues(t, []int{9, 5, 1, 3, 1}, backing) // the result is written over the unread items
+	_, ok := a.Next()
+	require.False(t, ok)
+
+	require.EqualValues(t, []int{}, ToSlice[int](IntersectInPlace(nil, NewSliceStream([]int{1}), true)))
+}
+
 func TestSliceStream(t *testing.T) {
 	s1 := NewSliceStream([]int{1, 2, 3})
 	s2 := ToSlice[int](s1)
 	require.EqualValues(t, s2, []int{1, 2, 3})
 }
 
+func TestToSliceFromSliceStream(t *testing.T) {
+	backing := make([]int, 3, 10)
+	copy(backing, []int{1, 2, 3})
+	s := NewSliceStream(backing)
+	s.Next()
+
+	remaining := ToSlice[int](s)
+	require.EqualValues(t, []int{2, 3}, remaining)
+	_, ok := s.Next()
+	require.False(t, ok) // drained
+
+	_ = append(remaining, 4) // must not write into the spare capacity of the original slice
+	require.EqualValues(t, 0, backing[:4][3])
+	require.EqualValues(t, []int{}, ToSlice[int](s))
+}
+
+func TestAppendTo(t *testing.T) {
+	buf := make([]int, 0, 10)
+	buf = append(buf, 100)
+
+	buf = AppendTo[int](buf, Union[int](NewSliceStream([]int{1, 3}), NewSliceStream([]int{2}), true))
+	require.EqualValues(t, []int{100, 1, 2, 3}, buf)
+
+	buf = AppendTo[int](buf, NewSliceStream([]int{4, 5}))
+	require.EqualValues(t, []int{100, 1, 2, 3, 4, 5}, buf)
+	require.Equal(t, 10, cap(buf)) // no reallocation
+
+	// reuse the buffer for another query
+	buf = AppendTo[int](buf[:0], NewSliceStream([]int{7}))
+	require.EqualValues(t, []int{7}, buf)
+}
+
+func TestToSliceCap(t *testing.T) {
+	s := NewChannelStream[int]()
+	go func() {
+		s.Push(1)
+		s.Push(2)
+		s.Close()
+	}()
+	result := ToSliceCap[int](s, 10)
+	require.EqualValues(t, []int{1, 2}, result)
+	require.Equal(t, 10, cap(result))
+
+	items := []int{1, 2}
+	result = ToSliceCap[int](NewSliceStream(items), 10)
+	require.EqualValues(t, items, result)
+	require.Equal(t, 10, cap(result)) // a copy, the backing slice is too small
+	result = ToSliceCap[int](NewSliceStream(items), 2)
+	require.Same(t, &items[0], &result[0]) // the backing slice fits the hint
+}
+
 func TestChannelStream(t *testing.T) {
 	s1 := NewChannelStream[int]()
 	go func() {
@@ -128,6 +246,128 @@ func TestChannelStream(t *testing.T) {
 	require.EqualValues(t, []int{1, 2, 3}, fetchedData)
 }
 
+func TestChannelStreamTryPush(t *testing.T) {
+	s := NewChannelStream[int]()
+	require.False(t, s.TryPush(1)) // nobody reads, Push would block here
+
+	received := make(chan int)
+	go func() {
+		item, _ := s.Next()
+		received <- item
+	}()
+	for !s.TryPush(2) { // wait for the reader
+		runtime.Gosched()
+	}
+	require.Equal(t, 2, <-received)
+
+	s.Close()
+	_, ok := s.Next()
+	require.False(t, ok)
+}
+
+func TestChannelStreamCloseTwice(t *testing.T) {
+	s := NewChannelStream[int]()
+	s.Close()
+	require.NotPanics(t, s.Close)
+	_, ok := s.Next()
+	require.False(t, ok)
+
+	produced := NewChannelStream[int]()
+	go func() {
+		produced.Push(1)
+		produced.Close()
+	}()
+	require.EqualValues(t, []int{1}, ToSlice[int](produced))
+	produced.Close() // user code tidies up after the producer
+	_, ok = produced.Next()
+	require.False(t, ok)
+}
+
+func TestBatchNext(t *testing.T) {
+	channel := NewChannelStream[int]()
+	go func() {
+		for i := 0; i < 10; i++ {
+			channel.Push(i)
+		}
+		channel.Close()
+	}()
+	streams := map[string]SortedNumbersStream[int]{
+		"slice":    NewSliceStream(ToSlice(NewRangeStream(0, 10, 1))),
+		"range":    NewRangeStream(0, 10, 1),
+		"channel":  channel,
+		"directed": WithDirection[int](&linearStream[int]{NewRangeStream(0, 10, 1)}, true),
+	}
+	for name, stream := range streams {
+		t.Run(name, func(t *testing.T) {
+			batches := stream.(BatchNext[int])
+			var items []int
+			buf := make([]int, 3)
+			for {
+				n, ok := batches.NextN(buf)
+				if !ok {
+					break
+				}
+				require.True(t, n > 0 && n <= 3)
+				items = append(items, buf[:n]...)
+			}
+			require.EqualValues(t, ToSlice(NewRangeStream(0, 10, 1)), items)
+			n, ok := batches.NextN(buf)
+			require.False(t, ok)
+			require.Equal(t, 0, n)
+		})
+	}
+}
+
+// BenchmarkBatchNext compares reading 1M items one by one and in batches
+func BenchmarkBatchNext(b *testing.B) {
+	const items = 1_000_000
+	channelUnion := func() SortedNumbersStream[int] { // an operand which is not Sized prevents the shortcut
+		return Union[int](NewRangeStream(0, items, 1), &linearStream[int]{NewSliceStream([]int{})}, true, WithChannelBackend(batchSize))
+	}
+	b.Run("next", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			var s SortedNumbersStream[int] = NewRangeStream(0, items, 1)
+			for {
+				if _, ok := s.Next(); !ok {
+					break
+				}
+			}
+		}
+	})
+	b.Run("next n", func(b *testing.B) {
+		buf := make([]int, batchSize)
+		for i := 0; i < b.N; i++ {
+			var s BatchNext[int] = NewRangeStream(0, items, 1)
+			for {
+				if _, ok := s.NextN(buf); !ok {
+					break
+				}
+			}
+		}
+	})
+	b.Run("channel next", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := channelUnion()
+			for {
+				if _, ok := s.Next(); !ok {
+					break
+				}
+			}
+		}
+	})
+	b.Run("channel next n", func(b *testing.B) {
+		buf := make([]int, batchSize)
+		for i := 0; i < b.N; i++ {
+			s := channelUnion().(BatchNext[int])
+			for {
+				if _, ok := s.NextN(buf); !ok {
+					break
+				}
+			}
+		}
+	})
+}
+
 func TestUnion(t *testing.T) {
 	type test struct {
 		a, b, result []int
@@ -142,6 +382,7 @@ func TestUnion(t *testing.T) {
 		{[]int{1}, []int{0, 2}, []int{0, 1, 2}, true},
 		{[]int{1, 2, 3}, []int{0}, []int{0, 1, 2, 3}, true},
 		{[]int{1}, []int{0, 1, 2, 3}, []int{0, 1, 2, 3}, true},
+		{[]int{1}, []int{2, 3}, []int{1, 2, 3}, true},
 		// desc
 		{[]int{}, []int{}, []int{}, false},
 		{[]int{}, []int{1}, []int{1}, false},
@@ -150,6 +391,7 @@ func TestUnion(t *testing.T) {
 		{[]int{1}, []int{2, 0}, []int{2, 1, 0}, false},
 		{[]int{3, 2, 1}, []int{0}, []int{3, 2, 1, 0}, false},
 		{[]int{1}, []int{3, 2, 1, 0}, []int{3, 2, 1, 0}, false},
+		{[]int{3}, []int{2, 1}, []int{3, 2, 1}, false},
 	}
 
 	for i, tt := range tests {
@@ -209,6 +451,12 @@ func TestDiff(t *testing.T) {
 		{[]int{0, 1}, []int{0, 1, 2}, []int{}, true},
 		{[]int{1, 2, 3}, []int{0, 1, 2}, []int{3}, true},
 		{[]int{0, 1, 2}, []int{1}, []int{0, 2}, true},
+		// repeated items: set semantics, not bag subtraction (which would give [1, 2])
+		{[]int{1, 1, 2}, []int{1}, []int{2}, true},
+		{[]int{1, 1, 2}, []int{1, 1}, []int{2}, true},
+		{[]int{1, 2}, []int{1, 1}, []int{2}, true},
+		{[]int{1, 1, 1, 2}, []int{1, 3}, []int{2}, true},
+		{[]int{1, 1, 2}, []int{2}, []int{1, 1}, true},
 		// desc
 		{[]int{}, []int{}, []int{}, false},
 		{[]int{}, []int{1}, []int{}, false},
@@ -218,6 +466,7 @@ func TestDiff(t *testing.T) {
 		{[]int{1, 0}, []int{2, 1, 0}, []int{}, false},
 		{[]int{3, 2, 1}, []int{2, 1, 0}, []int{3}, false},
 		{[]int{2, 1, 0}, []int{1}, []int{2, 0}, false},
+		{[]int{2, 1, 1}, []int{1}, []int{2}, false},
 	}
 
 	for i, tt := range tests {
@@ -230,6 +479,80 @@ func TestDiff(t *testing.T) {
 	}
 }
 
+func TestDiffRemoveOnce(t *testing.T) {
+	type test struct {
+		a, b, result []int
+		asc          bool
+	}
+	tests := []test{
+		{[]int{1, 1, 2}, []int{1}, []int{1, 2}, true},
+		{[]int{1, 1, 2}, []int{1, 1}, []int{2}, true},
+		{[]int{1, 2}, []int{1, 1}, []int{2}, true},
+		{[]int{1, 1, 1, 2}, []int{1, 3}, []int{1, 1, 2}, true},
+		{[]int{1, 1, 2, 2, 2}, []int{0, 1, 2, 2}, []int{1, 2}, true},
+		{[]int{1, 1, 2}, []int{}, []int{1, 1, 2}, true},
+		{[]int{2, 1, 1}, []int{1}, []int{2, 1}, false},
+		{[]int{2, 2, 1}, []int{3, 2, 0}, []int{2, 1}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			c := Diff[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc, WithDiffMode(RemoveOnce))
+			require.EqualValues(t, tt.result, ToSlice(c))
+		})
+	}
+
+	// the default mode is RemoveAll
+	c := Diff[int](NewSliceStream([]int{1, 1, 2}), NewSliceStream([]int{1}), true, WithDiffMode(RemoveAll))
+	require.EqualValues(t, []int{2}, ToSlice(c))
+}
+
+func TestZip(t *testing.T) {
+	one, two, three := 1, 2, 3
+	type test struct {
+		a, b   []int
+		result []Pair[int]
+		asc    bool
+	}
+	tests := []test{
+		{[]int{}, []int{}, []Pair[int]{}, true},
+		{[]int{1, 2}, []int{2, 3}, []Pair[int]{{&one, nil}, {&two, &two}, {nil, &three}}, true},
+		{[]int{3}, []int{2, 1}, []Pair[int]{{&three, nil}, {nil, &two}, {nil, &one}}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			a := NewSliceStream(tt.a)
+			b := NewSliceStream(tt.b)
+			c := Zip[int](a, b, tt.asc)
+			require.EqualValues(t, tt.result, ToSlice(c))
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	type test struct {
+		a, b                   []string
+		union, intersect, diff []string
+		asc                    bool
+	}
+	tests := []test{
+		{[]string{}, []string{}, []string{}, []string{}, []string{}, true},
+		{[]string{""}, []string{"", "a"}, []string{"", "a"}, []string{""}, []string{}, true},
+		{[]string{"a", "b", "c"}, []string{"b", "d"}, []string{"a", "b", "c", "d"}, []string{"b"}, []string{"a", "c"}, true},
+		{[]string{"Z", "a"}, []string{"a", "z"}, []string{"Z", "a", "z"}, []string{"a"}, []string{"Z"}, true},
+		{[]string{"c", "b", "a"}, []string{"d", "b"}, []string{"d", "c", "b", "a"}, []string{"b"}, []string{"c", "a"}, false},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.union, ToSlice(Union[string](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)))
+			require.EqualValues(t, tt.intersect, ToSlice(Intersect[string](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)))
+			require.EqualValues(t, tt.diff, ToSlice(Diff[string](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc)))
+		})
+	}
+}
+
 func TestComposition(t *testing.T) {
 	a := NewSliceStream([]int{1, 2, 3})
 	b := NewSliceStream([]int{2, 3})
@@ -237,3 +560,222 @@ func TestComposition(t *testing.T) {
 	result := Diff[int](Intersect[int](a, b, true), c, true)
 	require.EqualValues(t, []int{2}, ToSlice(result))
 }
+
+func TestDirectionPropagation(t *testing.T) {
+	a := WithDirection[int](NewSliceStream([]int{3, 2, 1}), false)
+	b := WithDirection[int](NewSliceStream([]int{3, 2}), false)
+	c := WithDirection[int](NewSliceStream([]int{3}), false)
+
+	intersection := Intersect[int](a, b, false)
+	require.False(t, intersection.(DirectedStream[int]).Asc())
+
+	require.Panics(t, func() { Diff[int](intersection, c, true) })
+	require.Panics(t, func() { Union[int](NewSliceStream([]int{1}), c, true) })
+
+	result := Diff[int](intersection, c, false)
+	require.EqualValues(t, []int{2}, ToSlice(result))
+}
+
+const benchmarkSize = 1_000_000
+
+// benchmarkOperands returns operands of the given overlap in the given order:
+// "disjoint" interleaves a and b without common items (worst case), "identical" has all items in common (best case)
+func benchmarkOperands(overlap string, asc bool) (a, b []int) {
+	a, b = make([]int, benchmarkSize), make([]int, benchmarkSize)
+	for i := range a {
+		switch overlap {
+		case "disjoint":
+			a[i], b[i] = i*2, i*2+1
+		case "half":
+			a[i], b[i] = i, i+benchmarkSize/2
+		case "identical":
+			a[i], b[i] = i, i
+		}
+	}
+	if !asc {
+		for i, j := 0, len(a)-1; i < j; i, j = i+1, j-1 {
+			a[i], a[j] = a[j], a[i]
+			b[i], b[j] = b[j], b[i]
+		}
+	}
+	return
+}
+
+// BenchmarkIntersectInPlace shows the allocations saved by writing the result over the operand,
+// both variants copy the operand first as IntersectInPlace destroys it
+func BenchmarkIntersectInPlace(b *testing.B) {
+	s1, s2 := benchmarkOperands("half", true)
+	operand := make([]int, len(s1))
+	b.Run("intersect", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copy(operand, s1)
+			ToSlice(Intersect[int](NewSliceStream(operand), NewSliceStream(s2), true))
+		}
+	})
+	b.Run("in place", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			copy(operand, s1)
+			ToSlice[int](IntersectInPlace(NewSliceStream(operand), NewSliceStream(s2), true))
+		}
+	})
+}
+
+func benchmarkOperation(b *testing.B, op func(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int]) {
+	for _, overlap := range []string{"disjoint", "half", "identical"} {
+		for _, asc := range []bool{true, false} {
+			s1, s2 := benchmarkOperands(overlap, asc)
+			b.Run(fmt.Sprintf("%s asc=%t", overlap, asc), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					result := op(NewSliceStream(s1), NewSliceStream(s2), asc)
+					for _, ok := result.Next(); ok; _, ok = result.Next() {
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkUnion(b *testing.B)     { benchmarkOperation(b, Union[int]) }
+func BenchmarkIntersect(b *testing.B) { benchmarkOperation(b, Intersect[int]) }
+func BenchmarkDiff(b *testing.B)      { benchmarkOperation(b, Diff[int]) }
+
+// BenchmarkIntersectMerge hides slice operands from the Intersect fast path to compare it with the merge
+func BenchmarkIntersectMerge(b *testing.B) {
+	benchmarkOperation(b, func(stream1, stream2 SortedNumbersStream[int], asc bool, opts ...Option) SortedNumbersStream[int] {
+		return Intersect[int](linearStream[int]{stream1}, linearStream[int]{stream2}, asc, opts...)
+	})
+}
+
+// BenchmarkChannelOverhead isolates the cost of passing items through ChannelStream
+func BenchmarkChannelOverhead(b *testing.B) {
+	b.Run("channel", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			s := NewChannelStream[int]()
+			go func() {
+				for j := 0; j < benchmarkSize; j++ {
+					s.Push(j)
+				}
+				s.Close()
+			}()
+			for _, ok := s.Next(); ok; _, ok = s.Next() {
+			}
+		}
+	})
+	b.Run("slice", func(b *testing.B) {
+		items, _ := benchmarkOperands("identical", true)
+		for i := 0; i < b.N; i++ {
+			s := NewSliceStream(items)
+			for _, ok := s.Next(); ok; _, ok = s.Next() {
+			}
+		}
+	})
+}
+
+func BenchmarkToSlice(b *testing.B) {
+	items, _ := benchmarkOperands("identical", true)
+	// a stream which is not a SliceStream to measure appending
+	stream := func() SortedNumbersStream[int] { return WithDirection[int](NewSliceStream(items), true) }
+	b.Run("grow", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ToSlice(stream())
+		}
+	})
+	b.Run("cap hint", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ToSliceCap(stream(), len(items))
+		}
+	})
+	b.Run("slice stream", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ToSlice[int](NewSliceStream(items))
+		}
+	})
+	b.Run("slice stream cap hint", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			ToSliceCap[int](NewSliceStream(items), len(items))
+		}
+	})
+}
+
+func TestEmptyOperandFastPath(t *testing.T) {
+	a := NewSliceStream([]int{1, 2})
+	result := Intersect[int](NewSliceStream([]int{}), a, true)
+	require.IsType(t, &directedStream[int]{}, result)
+	require.IsType(t, &SliceStream[int]{}, result.(*directedStream[int]).SortedNumbersStream) // no goroutine
+	require.EqualValues(t, []int{}, ToSlice(result))
+	require.EqualValues(t, 0, a.pos) // not consumed
+
+	a = NewSliceStream([]int{1, 2})
+	result = Diff[int](a, NewSliceStream([]int{}), true)
+	require.Same(t, a, result.(*directedStream[int]).SortedNumbersStream) // forwarded
+	require.EqualValues(t, []int{1, 2}, ToSlice(result))
+
+	result = Union[int](NewSliceStream([]int{}), NewSliceStream([]int{3, 2}), false)
+	require.False(t, result.(DirectedStream[int]).Asc())
+	require.EqualValues(t, []int{3, 2}, ToSlice(result))
+
+	// results of fast paths are detected as empty too
+	empty := Intersect[int](NewSliceStream([]int{}), NewSliceStream([]int{1}), true)
+	require.EqualValues(t, []int{1}, ToSlice(Union[int](empty, NewSliceStream([]int{1}), true)))
+}
+
+func TestNilOperand(t *testing.T) {
+	b := func() SortedNumbersStream[int] { return NewSliceStream([]int{1, 2}) }
+	require.EqualValues(t, []int{1, 2}, ToSlice(Union[int](nil, b(), true)))
+	require.EqualValues(t, []int{1, 2}, ToSlice(Union[int](b(), nil, true)))
+	require.EqualValues(t, []int{}, ToSlice(Union[int](nil, nil, true)))
+	require.EqualValues(t, []int{}, ToSlice(Intersect[int](nil, b(), true)))
+	require.EqualValues(t, []int{}, ToSlice(Intersect[int](b(), nil, true)))
+	require.EqualValues(t, []int{}, ToSlice(Diff[int](nil, b(), true)))
+	require.EqualValues(t, []int{1, 2}, ToSlice(Diff[int](b(), nil, true)))
+	require.Len(t, ToSlice(Zip[int](nil, b(), true)), 2)
+}
+
+func TestSized(t *testing.T) {
+	s := NewSliceStream([]int{1, 2, 3})
+	s.Next()
+	n, ok := s.Len()
+	require.True(t, ok)
+	require.Equal(t, 2, n)
+
+	n, ok = WithDirection[int](s, true).(Sized[int]).Len()
+	require.True(t, ok)
+	require.Equal(t, 2, n)
+
+	_, ok = WithDirection[int](NewChannelStream[int](), true).(Sized[int]).Len()
+	require.False(t, ok)
+
+	require.True(t, isDrained[int](NewRangeStream(1, 1, 1)))
+}
+
+// zero values are real items and must never be confused with the absence of an item
+func TestZeroValueItems(t *testing.T) {
+	ints := func(items ...int) SortedNumbersStream[int] { return NewSliceStream(items) }
+	require.EqualValues(t, []int{0}, ToSlice(Intersect[int](ints(0), ints(0), true)))
+	require.EqualValues(t, []int{0}, ToSlice(Union[int](ints(), ints(0), true)))
+	require.EqualValues(t, []int{0}, ToSlice(Union[int](ints(0), ints(), true)))
+	require.EqualValues(t, []int{0}, ToSlice(Union[int](ints(0), ints(0), true)))
+	require.EqualValues(t, []int{-1, 0, 1}, ToSlice(Union[int](ints(-1, 0), ints(0, 1), true)))
+	require.EqualValues(t, []int{0}, ToSlice(Diff[int](ints(0, 1), ints(1), true)))
+	require.EqualValues(t, []int{}, ToSlice(Diff[int](ints(0), ints(0), true)))
+	require.EqualValues(t, []int{0}, ToSlice(IntersectSmart[int](ints(0), ints(0), true)))
+	require.EqualValues(t, []int{1, 0}, ToSlice(Union[int](ints(1, 0), ints(0), false)))
+
+	strings := func(items ...string) SortedNumbersStream[string] { return NewSliceStream(items) }
+	require.EqualValues(t, []string{""}, ToSlice(Intersect[string](strings(""), strings(""), true)))
+	require.EqualValues(t, []string{""}, ToSlice(Union[string](strings(), strings(""), true)))
+	require.EqualValues(t, []string{"", "a"}, ToSlice(Diff[string](strings("", "a"), strings("b"), true)))
+
+	zero := 0
+	require.EqualValues(t, []Pair[int]{{&zero, &zero}}, ToSlice(Zip[int](ints(0), ints(0), true)))
+
+	var buf bytes.Buffer
+	require.NoError(t, EncodeStream[int](ints(0, 0), &buf))
+	require.EqualValues(t, []int{0, 0}, ToSlice[int](DecodeStream[int](&buf)))
+}
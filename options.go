@@ -0,0 +1,248 @@
+package sorted_numeric_streams
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Option configures an operation, e.g. Union(a, b, asc, WithProgress(1000, report))
+type Option func(*config)
+
+type config struct {
+	progressEvery int
+	onProgress    func(readA, readB int)
+	errs          chan error // set by *Async variants to report operand failures
+	diffMode      DiffMode
+	backend       backend
+	channelCap    int
+	adaptiveMin   int
+	adaptiveMax   int
+
+	stallAfter time.Duration
+	onStall    func()
+
+	ctx context.Context
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// allowsShortcuts tells if an operation may skip the merge (see isDrained), options observing the merge prevent that
+func (cfg *config) allowsShortcuts() bool {
+	return cfg.onProgress == nil && cfg.errs == nil && cfg.ctx == nil
+}
+
+// WithProgress makes the operation report the number of items read from each operand every `every` reads,
+// and once more when the merge is finished.
+// The callback runs in its own goroutine and never blocks the merge: reports made while it is busy are skipped.
+func WithProgress(every int, onProgress func(readA, readB int)) Option {
+	if every < 1 {
+		every = 1
+	}
+	return func(cfg *config) {
+		cfg.progressEvery = every
+		cfg.onProgress = onProgress
+	}
+}
+
+// DiffMode tells how Diff treats repeated items
+type DiffMode int
+
+const (
+	// RemoveAll removes every occurrence of an item found in stream2 (set semantics): [1,1,2] \ [1] gives [2]
+	RemoveAll DiffMode = iota
+	// RemoveOnce removes one occurrence per occurrence in stream2 (bag subtraction): [1,1,2] \ [1] gives [1,2]
+	RemoveOnce
+)
+
+// WithDiffMode sets how Diff treats repeated items, RemoveAll by default. Other operations ignore it
+func WithDiffMode(mode DiffMode) Option {
+	return func(cfg *config) {
+		cfg.diffMode = mode
+	}
+}
+
+// WithStallDetector calls onStall when the merge pushes no item to the result for d: an operand blocks
+// (e.g. stuck I/O) or nobody reads the result of the unbuffered channel. A stall is detected within d to 2d,
+// onStall runs in a watchdog goroutine once per stall, it is called again only after the merge makes progress.
+// Only the channel backend has a merge goroutine to watch, other backends ignore it
+func WithStallDetector(d time.Duration, onStall func()) Option {
+	return func(cfg *config) {
+		cfg.stallAfter = d
+		cfg.onStall = onStall
+	}
+}
+
+// WithContext stops the operation once ctx is done: the merge reads no more items from the operands and the result ends.
+// With the channel backend Err of the result reports the error of ctx and the results merged but not read yet
+// are dropped, unless WithPartialOnCancel takes them. The slice backend returns the items merged before the cancellation
+func WithContext(ctx context.Context) Option {
+	return func(cfg *config) { cfg.ctx = ctx }
+}
+
+// WithPartialOnCancel passes the results of the operation cancelled by WithContext which were merged but not read
+// to flush, instead of dropping them, so an interactive query can show partial results on a timeout:
+//
+//	result := WithPartialOnCancel(Union(a, b, true, WithContext(ctx)), show)
+//
+// flush gets them in order, so together with the read items they are all the merged items. It is called once and only
+// on cancellation, before the result ends (or right away if it is cancelled already). Only results of the channel
+// backend hold unread items, other streams are returned as is
+func WithPartialOnCancel[T any](result SortedNumbersStream[T], flush func(unread []T)) SortedNumbersStream[T] {
+	stream := result
+	if d, ok := stream.(*directedStream[T]); ok {
+		stream = d.SortedNumbersStream
+	}
+	if c, ok := stream.(*ChannelStream[T]); ok {
+		c.onCancel(flush)
+	}
+	return result
+}
+
+// contextStream ends the operand once the context is done, so the merge stops
+type contextStream[T any] struct {
+	SortedNumbersStream[T]
+	ctx context.Context
+}
+
+func (s *contextStream[T]) Next() (item T, ok bool) {
+	if s.ctx.Err() != nil {
+		return
+	}
+	return s.SortedNumbersStream.Next()
+}
+
+// backend tells how an operation delivers its result
+type backend int
+
+const (
+	channelBackend backend = iota
+	sliceBackend
+	pullBackend
+)
+
+// WithChannelBackend merges in a goroutine sending results over a channel with the given capacity (the default, unbuffered)
+// A buffer lets the merge run ahead of a slow reader
+func WithChannelBackend(capacity int) Option {
+	if capacity < 0 {
+		capacity = 0
+	}
+	return func(cfg *config) {
+		cfg.backend = channelBackend
+		cfg.channelCap = capacity
+		cfg.adaptiveMin, cfg.adaptiveMax = 0, 0
+	}
+}
+
+// WithAdaptiveBuffer is the channel backend with a buffer resized between min and max items as the merge goes
+// (see adaptiveQueue for the heuristic): it grows while the reader is slower than the merge, so the merge can run
+// ahead, and shrinks back while the reader waits for items, so idle buffer memory is released
+func WithAdaptiveBuffer(min, max int) Option {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	return func(cfg *config) {
+		cfg.backend = channelBackend
+		cfg.adaptiveMin, cfg.adaptiveMax = min, max
+	}
+}
+
+// WithSliceBackend merges eagerly in the calling goroutine and returns the materialized result,
+// best for small operands where a goroutine and a channel cost more than the merge itself
+func WithSliceBackend() Option {
+	return func(cfg *config) { cfg.backend = sliceBackend }
+}
+
+// WithPullBackend merges lazily in the goroutine reading the result, without goroutines or channels (see Compose)
+func WithPullBackend() Option {
+	return func(cfg *config) { cfg.backend = pullBackend }
+}
+
+// progressReporter counts operand reads and hands them over to the callback
+type progressReporter struct {
+	every        int
+	readA, readB int
+	reports      chan [2]int
+}
+
+func newProgressReporter(every int, onProgress func(readA, readB int)) *progressReporter {
+	r := &progressReporter{every: every, reports: make(chan [2]int, 1)}
+	go func() {
+		for report := range r.reports {
+			onProgress(report[0], report[1])
+		}
+	}()
+	return r
+}
+
+func (r *progressReporter) tick() {
+	if (r.readA+r.readB)%r.every != 0 {
+		return
+	}
+	select {
+	case r.reports <- [2]int{r.readA, r.readB}:
+	default: // the callback is still busy
+	}
+}
+
+// finish delivers the final counts, it may wait for the callback, so call it after the result is closed
+func (r *progressReporter) finish() {
+	r.reports <- [2]int{r.readA, r.readB}
+	close(r.reports)
+}
+
+// progressStream counts items read from the operand
+type progressStream[T any] struct {
+	SortedNumbersStream[T]
+	reporter *progressReporter
+	read     *int
+}
+
+func (s *progressStream[T]) Next() (item T, ok bool) {
+	if item, ok = s.SortedNumbersStream.Next(); ok {
+		*s.read++
+		s.reporter.tick()
+	}
+	return
+}
+
+// stallDetector watches the number of pushed items, the merge only increments a counter
+type stallDetector struct {
+	pushed atomic.Int64
+	done   chan struct{}
+}
+
+func newStallDetector(d time.Duration, onStall func()) *stallDetector {
+	s := &stallDetector{done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+		seen, stalled := int64(0), false
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+			}
+			if pushed := s.pushed.Load(); pushed != seen {
+				seen, stalled = pushed, false
+			} else if !stalled {
+				stalled = true
+				onStall()
+			}
+		}
+	}()
+	return s
+}
+
+// stop ends the watch once the merge is finished
+func (s *stallDetector) stop() { close(s.done) }
@@ -0,0 +1,215 @@
+package sorted_numeric_streams
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProgress(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports [][2]int
+		done    = make(chan struct{})
+	)
+	onProgress := func(readA, readB int) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, [2]int{readA, readB})
+		if readA+readB == 7 {
+			close(done) // the final report
+		}
+	}
+
+	a := NewSliceStream([]int{1, 2, 3, 4})
+	b := NewSliceStream([]int{2, 4, 6})
+	result := Union[int](a, b, true, WithProgress(2, onProgress))
+	require.EqualValues(t, []int{1, 2, 3, 4, 6}, ToSlice(result))
+
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, reports)
+	for _, report := range reports {
+		require.True(t, (report[0]+report[1])%2 == 0 || report == [2]int{4, 3})
+	}
+	require.Equal(t, [2]int{4, 3}, reports[len(reports)-1])
+}
+
+func TestWithProgressSlowCallback(t *testing.T) {
+	release := make(chan struct{})
+	onProgress := func(readA, readB int) { <-release } // blocks until the merge is over
+
+	items := make([]int, 10_000)
+	for i := range items {
+		items[i] = i
+	}
+	result := Intersect[int](NewSliceStream(items), NewSliceStream(items), true, WithProgress(1, onProgress))
+	require.Len(t, ToSlice(result), len(items)) // the merge was not blocked
+	close(release)
+}
+
+func TestWithProgressOnEmptyOperand(t *testing.T) {
+	reported := make(chan [2]int, 1)
+	result := Diff[int](NewSliceStream([]int{1}), NewSliceStream([]int{}), true, WithProgress(10, func(readA, readB int) {
+		reported <- [2]int{readA, readB}
+	}))
+	require.EqualValues(t, []int{1}, ToSlice(result))
+	require.Equal(t, [2]int{1, 0}, <-reported)
+}
+
+func TestBackends(t *testing.T) {
+	backends := map[string]Option{
+		"channel":          WithChannelBackend(0),
+		"buffered channel": WithChannelBackend(16),
+		"slice":            WithSliceBackend(),
+		"pull":             WithPullBackend(),
+	}
+	for name, backend := range backends {
+		t.Run(name, func(t *testing.T) {
+			a := func() SortedNumbersStream[int] { return NewSliceStream([]int{1, 2, 3, 5}) }
+			b := func() SortedNumbersStream[int] { return NewSliceStream([]int{2, 3, 4}) }
+
+			union := Union[int](a(), b(), true, backend)
+			require.True(t, union.(DirectedStream[int]).Asc())
+			require.EqualValues(t, []int{1, 2, 3, 4, 5}, ToSlice(union))
+			require.EqualValues(t, []int{2, 3}, ToSlice(Intersect[int](a(), b(), true, backend)))
+			require.EqualValues(t, []int{1, 5}, ToSlice(Diff[int](a(), b(), true, backend)))
+		})
+	}
+}
+
+func TestBackendsReportProgressAndFailures(t *testing.T) {
+	for _, backend := range []Option{WithSliceBackend(), WithPullBackend(), WithChannelBackend(4)} {
+		done := make(chan struct{})
+		progress := WithProgress(100, func(readA, readB int) {
+			if readA == 2 && readB == 3 { // the final report
+				close(done)
+			}
+		})
+		result := Union[int](NewSliceStream([]int{1, 2}), NewSliceStream([]int{1, 3, 4}), true, backend, progress)
+		require.EqualValues(t, []int{1, 2, 3, 4}, ToSlice(result))
+		<-done
+
+		failure := errors.New("disk failure")
+		result, errs := UnionAsync[int](&failingStream{[]int{1, 2}, failure}, NewSliceStream([]int{1, 3, 4}), true, backend)
+		require.EqualValues(t, []int{1, 2}, ToSlice(result))
+		require.ErrorIs(t, <-errs, failure)
+		_, open := <-errs
+		require.False(t, open)
+	}
+}
+
+func TestSliceBackendIsEager(t *testing.T) {
+	a, history := Record[int](NewSliceStream([]int{1, 2}))
+	result := Union[int](a, NewSliceStream([]int{3}), true, WithSliceBackend())
+	require.EqualValues(t, []int{1, 2}, history()) // merged before the result is read
+	n, ok := result.(Sized[int]).Len()
+	require.True(t, ok)
+	require.Equal(t, 3, n)
+}
+
+func TestWithStallDetector(t *testing.T) {
+	stalls := make(chan struct{}, 10)
+	onStall := func() { stalls <- struct{}{} }
+
+	slow := &slowStream{NewSliceStream([]int{1, 2}), 50 * time.Millisecond}
+	result := Union[int](slow, NewSliceStream([]int{3}), true, WithStallDetector(5*time.Millisecond, onStall))
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(result))
+	require.NotEmpty(t, stalls)
+
+	// nobody reads the unbuffered result
+	idle := Union[int](NewSliceStream([]int{1, 2}), NewSliceStream([]int{3}), true, WithStallDetector(5*time.Millisecond, onStall))
+	for len(stalls) > 0 {
+		<-stalls
+	}
+	select {
+	case <-stalls:
+	case <-time.After(time.Second):
+		t.Fatal("the stall is not detected")
+	}
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(idle))
+}
+
+func TestWithStallDetectorQuiet(t *testing.T) {
+	var stalls atomic.Int32
+	result := Union[int](NewSliceStream([]int{1, 2}), NewSliceStream([]int{3}), true, WithStallDetector(time.Minute, func() { stalls.Add(1) }))
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(result))
+	require.EqualValues(t, 0, stalls.Load())
+}
+
+func TestWithPartialOnCancel(t *testing.T) {
+	type test struct {
+		name    string
+		backend Option
+	}
+	tests := []test{
+		{"unbuffered", WithChannelBackend(0)},
+		{"buffered", WithChannelBackend(16)},
+		{"adaptive", WithAdaptiveBuffer(4, 64)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			flushed := make(chan []int, 1)
+			result := Union[int](NewRangeStream(0, 1_000_000_000, 2), NewRangeStream(1, 1_000_000_000, 2), true, tt.backend, WithContext(ctx))
+			result = WithPartialOnCancel(result, func(unread []int) { flushed <- unread })
+
+			var read []int
+			for i := 0; i < 5; i++ {
+				item, _ := result.Next()
+				read = append(read, item)
+			}
+			cancel()
+			partial := <-flushed // the merge is blocked on the full result, so some results are not read
+			require.NotEmpty(t, partial)
+			rest := ToSlice(result)
+			require.ErrorIs(t, result.(Errorable).Err(), context.Canceled)
+
+			all := append(append(read, rest...), partial...)
+			require.EqualValues(t, ToSlice[int](NewRangeStream(0, len(all), 1)), all)
+		})
+	}
+}
+
+func TestWithPartialOnCancelAfterCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := Union[int](NewRangeStream(0, 1_000_000_000, 1), NewRangeStream(0, 0, 1), true, WithChannelBackend(8), WithContext(ctx))
+	first, _ := result.Next()
+	cancel()
+	rest := ToSlice(result) // the result ends once the operation is cancelled
+
+	var unread []int
+	WithPartialOnCancel(result, func(items []int) { unread = items })
+	all := append(append([]int{first}, rest...), unread...)
+	require.NotEmpty(t, unread)
+	require.EqualValues(t, ToSlice[int](NewRangeStream(0, len(all), 1)), all)
+
+	sliced := Union[int](NewSliceStream([]int{1}), nil, true, WithSliceBackend())
+	require.Equal(t, sliced, WithPartialOnCancel(sliced, func([]int) { t.Fatal("not cancelled") }))
+}
+
+func TestWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	result := Intersect[int](NewRangeStream(0, 1_000_000_000, 1), NewRangeStream(0, 1_000_000_000, 1), true, WithContext(ctx))
+	item, _ := result.Next()
+	require.Equal(t, 0, item)
+	cancel()
+	require.Less(t, len(ToSlice(result)), 3) // the dropped results and no more merging
+	require.ErrorIs(t, result.(Errorable).Err(), context.Canceled)
+
+	for _, backend := range []Option{WithSliceBackend(), WithPullBackend()} {
+		result := Union[int](NewSliceStream([]int{1, 2}), NewSliceStream([]int{3}), true, backend, WithContext(ctx))
+		require.EqualValues(t, []int{}, ToSlice(result))
+	}
+
+	result = Union[int](NewSliceStream([]int{1, 2}), NewSliceStream([]int{3}), true, WithContext(context.Background()))
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice(result))
+	require.NoError(t, result.(Errorable).Err())
+}
@@ -0,0 +1,140 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/exp/constraints"
+)
+
+// Pipeline is a set operation that does not acquire resources (goroutine, channel) until Start is called,
+// so a query plan can be built before it is executed. Stop cancels the operation at any point.
+// Next before Start (or after Stop) returns ok=false
+type Pipeline[T constraints.Ordered] struct {
+	stream1, stream2 SortedNumbersStream[T]
+	pick             picker[T]
+	stop             shouldStop
+	asc              bool
+
+	mu      sync.Mutex
+	result  chan T
+	done    chan struct{}
+	stopped bool
+	err     error
+}
+
+// UnionPipeline returns a not started Union of stream1 and stream2
+func UnionPipeline[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) *Pipeline[T] {
+	return newPipeline(stream1, stream2, unionPick[T], unionStop, asc)
+}
+
+// IntersectPipeline returns a not started Intersect of stream1 and stream2
+func IntersectPipeline[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) *Pipeline[T] {
+	return newPipeline(stream1, stream2, intersectPick[T], intersectStop, asc)
+}
+
+// DiffPipeline returns a not started Diff of stream1 and stream2
+func DiffPipeline[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) *Pipeline[T] {
+	return newPipeline(stream1, stream2, newDiffPick[T](), diffStop, asc)
+}
+
+func newPipeline[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], pick picker[T], stop shouldStop, asc bool) *Pipeline[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	return &Pipeline[T]{
+		stream1: stream1,
+		stream2: stream2,
+		pick:    pick,
+		stop:    stop,
+		asc:     asc,
+		done:    make(chan struct{}),
+	}
+}
+
+// startStopper is implemented by operands which are pipelines themselves
+type startStopper interface {
+	Start()
+	Stop()
+}
+
+// Start launches the operation together with operand pipelines (so a plan tree is started from its root)
+// Repeated calls and calls after Stop do nothing
+func (p *Pipeline[T]) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.result != nil || p.stopped {
+		return
+	}
+	for _, operand := range []SortedNumbersStream[T]{p.stream1, p.stream2} {
+		if s, ok := operand.(startStopper); ok {
+			s.Start()
+		}
+	}
+
+	result := make(chan T)
+	p.result = result
+	go func() {
+		defer close(result)
+		defer func() {
+			if r := recover(); r != nil {
+				p.err = fmt.Errorf("%w: %v", ErrPanic, r)
+			}
+		}()
+		m := newMerger(p.stream1, p.stream2, compareOrdered[T], p.stop, p.asc)
+		for {
+			a, b, ok := m.next()
+			if !ok {
+				return
+			}
+			if item := p.pick(a, b); item != nil {
+				select {
+				case result <- *item:
+				case <-p.done:
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Stop cancels the operation and operand pipelines, the background goroutine exits without draining the operands
+func (p *Pipeline[T]) Stop() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return
+	}
+	p.stopped = true
+	close(p.done)
+	for _, operand := range []SortedNumbersStream[T]{p.stream1, p.stream2} {
+		if s, ok := operand.(startStopper); ok {
+			s.Stop()
+		}
+	}
+}
+
+func (p *Pipeline[T]) Asc() bool { return p.asc }
+
+// Err returns ErrPanic if the operation goroutine panicked, once the result is drained
+func (p *Pipeline[T]) Err() error { return p.err }
+
+func (p *Pipeline[T]) Next() (item T, ok bool) {
+	p.mu.Lock()
+	result := p.result
+	p.mu.Unlock()
+	if result == nil {
+		return // not started
+	}
+
+	select {
+	case <-p.done:
+		return
+	default:
+	}
+	select {
+	case item, ok = <-result:
+		return
+	case <-p.done:
+		return
+	}
+}
@@ -0,0 +1,76 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// countingStream is an endless asc stream of 0,1,2...
+type countingStream struct{ next int }
+
+func (s *countingStream) Next() (int, bool) {
+	s.next++
+	return s.next - 1, true
+}
+
+func TestPipelineLazyStart(t *testing.T) {
+	a := NewSliceStream([]int{1, 2, 3})
+	b := NewSliceStream([]int{2, 3, 4})
+	p := IntersectPipeline[int](a, b, true)
+
+	_, ok := p.Next()
+	require.False(t, ok) // not started
+	require.EqualValues(t, 0, a.pos)
+
+	p.Start()
+	p.Start() // no effect
+	require.EqualValues(t, []int{2, 3}, ToSlice[int](p))
+}
+
+func TestPipelineTree(t *testing.T) {
+	a := NewSliceStream([]int{1, 2, 3})
+	b := NewSliceStream([]int{2, 3})
+	c := NewSliceStream([]int{3})
+	p := DiffPipeline[int](IntersectPipeline[int](a, b, true), c, true)
+
+	p.Start() // starts the nested pipeline as well
+	require.EqualValues(t, []int{2}, ToSlice[int](p))
+}
+
+func TestPipelineStop(t *testing.T) {
+	p := UnionPipeline[int](&countingStream{}, &countingStream{}, true)
+	p.Start()
+	item, ok := p.Next()
+	require.True(t, ok)
+	require.EqualValues(t, 0, item)
+
+	p.Stop()
+	p.Stop() // no effect
+	_, ok = p.Next()
+	require.False(t, ok)
+
+	// the goroutine exits despite endless operands
+	exited := make(chan struct{})
+	go func() {
+		for range p.result {
+		}
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatal("pipeline goroutine did not exit")
+	}
+}
+
+func TestPipelineStopBeforeStart(t *testing.T) {
+	a := NewSliceStream([]int{1})
+	p := UnionPipeline[int](a, NewSliceStream([]int{2}), true)
+	p.Stop()
+	p.Start()
+	_, ok := p.Next()
+	require.False(t, ok)
+	require.EqualValues(t, 0, a.pos) // resources were never acquired
+}
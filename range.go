@@ -0,0 +1,136 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+
+	"golang.org/x/exp/constraints"
+)
+
+// RangeStream generates the sequence from, from+step, ... up to `to` (exclusive) without storing it,
+// it is ascending for a positive step and descending for a negative one
+type RangeStream[T constraints.Integer] struct {
+	from, step T
+	n, pos     int // total number of items and the index of the next one
+}
+
+func (s *RangeStream[T]) Next() (item T, ok bool) {
+	if s.pos >= s.n {
+		return
+	}
+	item = s.from + T(s.pos)*s.step
+	s.pos++
+	return item, true
+}
+
+// NextN generates the next items into buf (see BatchNext)
+func (s *RangeStream[T]) NextN(buf []T) (n int, ok bool) {
+	n = min(len(buf), s.n-s.pos)
+	for i := range buf[:n] {
+		buf[i] = s.from + T(s.pos+i)*s.step
+	}
+	s.pos += n
+	return n, n > 0 || len(buf) == 0 && s.pos < s.n
+}
+
+// Len returns the number of remaining items
+func (s *RangeStream[T]) Len() (int, bool) { return s.n - s.pos, true }
+
+func (s *RangeStream[T]) Asc() bool { return s.step > 0 }
+
+// Seek skips items going before target arithmetically, asc must match the sign of the step
+func (s *RangeStream[T]) Seek(target T, asc bool) {
+	if asc != s.Asc() {
+		panic(fmt.Sprintf("seeking with asc=%t in a range with step %v", asc, s.step))
+	}
+	if asc && target <= s.from || !asc && target >= s.from {
+		return
+	}
+	// the distance to target and the step in the direction of the range, both are positive here
+	dist, step := distance(s.from, target), distance(0, s.step)
+	if !asc {
+		dist, step = distance(target, s.from), distance(s.step, 0)
+	}
+	// the index of the first item not going before target, rounding up
+	i := (dist-1)/step + 1
+	if i >= uint64(s.n) {
+		s.pos = s.n
+	} else if int(i) > s.pos {
+		s.pos = int(i)
+	}
+}
+
+// distance returns to-from for from < to, computed in uint64 so it does not overflow T
+// (the conversions sign-extend, and the modular subtraction gives the exact difference)
+func distance[T constraints.Integer](from, to T) uint64 { return uint64(to) - uint64(from) }
+
+func (s *RangeStream[T]) Reset() { s.pos = 0 }
+
+// DenseHint returns the bounds of the remaining items if they are consecutive integers (the step is 1 or -1)
+func (s *RangeStream[T]) DenseHint() (first, last T, ok bool) {
+	if s.step != 1 && !(s.step < 0 && s.step == ^T(0)) || s.pos >= s.n { // ^T(0) is -1 for signed types
+		return first, last, false
+	}
+	return s.from + T(s.pos)*s.step, s.from + T(s.n-1)*s.step, true
+}
+
+// NewRangeStream returns the stream of [from, to) with the given step, or of (to, from] going down
+// for a negative step, e.g. NewRangeStream(10, 0, -3) gives 10, 7, 4, 1. The step must not be zero
+func NewRangeStream[T constraints.Integer](from, to, step T) *RangeStream[T] {
+	if step == 0 {
+		panic("range step must not be zero")
+	}
+	n := 0
+	if step > 0 && to > from {
+		n = int((distance(from, to)-1)/distance(0, step) + 1)
+	}
+	if step < 0 && to < from {
+		n = int((distance(to, from)-1)/distance(step, 0) + 1)
+	}
+	return &RangeStream[T]{from: from, step: step, n: n}
+}
+
+// newClosedRange returns the stream of [first, last] going up (or of [last, first] going down for a negative step),
+// unlike NewRangeStream it includes last, which may be the largest (smallest) value of T
+func newClosedRange[T constraints.Integer](first, last, step T) *RangeStream[T] {
+	n := 0
+	if step > 0 && last >= first {
+		n = int(distance(first, last)/distance(0, step) + 1)
+	}
+	if step < 0 && last <= first {
+		n = int(distance(last, first)/distance(step, 0) + 1)
+	}
+	return &RangeStream[T]{from: first, step: step, n: n}
+}
+
+// Dense is implemented by streams which may know that their items are consecutive integers,
+// so operations can compute results from the bounds without reading the items
+type Dense[T constraints.Integer] interface {
+	// DenseHint returns the first and the last remaining items in the stream order,
+	// ok is false if the items are not consecutive
+	DenseHint() (first, last T, ok bool)
+}
+
+// IntersectDense is Intersect which computes the overlap of two dense operands (see Dense, e.g. RangeStream with step 1,
+// or -1 for desc operands) arithmetically, without iterating them: the result is a RangeStream and the operands are not read.
+// Otherwise, it falls back to Intersect
+func IntersectDense[T constraints.Integer](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+	dense1, ok1 := stream1.(Dense[T])
+	dense2, ok2 := stream2.(Dense[T])
+	if !ok1 || !ok2 {
+		return Intersect(stream1, stream2, asc)
+	}
+	first1, last1, ok1 := dense1.DenseHint()
+	first2, last2, ok2 := dense2.DenseHint()
+	if !ok1 || !ok2 {
+		return Intersect(stream1, stream2, asc)
+	}
+	if !asc {
+		if ^T(0) > 0 { // unsigned, there is no step going down
+			return Intersect(stream1, stream2, asc)
+		}
+		return newClosedRange(min(first1, first2), max(last1, last2), ^T(0))
+	}
+	return newClosedRange(max(first1, first2), min(last1, last2), 1)
+}
@@ -0,0 +1,100 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRangeStream(t *testing.T) {
+	type test struct {
+		from, to, step int
+		result         []int
+	}
+	tests := []test{
+		{0, 0, 1, []int{}},
+		{5, 0, 1, []int{}},
+		{0, 5, 1, []int{0, 1, 2, 3, 4}},
+		{0, 5, 2, []int{0, 2, 4}},
+		{0, 6, 2, []int{0, 2, 4}},
+		{-3, 3, 3, []int{-3, 0}},
+		{5, 0, -1, []int{5, 4, 3, 2, 1}},
+		{10, 0, -3, []int{10, 7, 4, 1}},
+		{0, 5, -1, []int{}},
+		{3, -3, -3, []int{3, 0}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := NewRangeStream(tt.from, tt.to, tt.step)
+			n, ok := s.Len()
+			require.True(t, ok)
+			require.Equal(t, len(tt.result), n)
+			require.EqualValues(t, tt.result, ToSlice[int](s))
+			n, _ = s.Len()
+			require.Equal(t, 0, n)
+		})
+	}
+
+	require.Panics(t, func() { NewRangeStream(0, 10, 0) })
+	require.False(t, NewRangeStream(5, 0, -1).Asc())
+	require.EqualValues(t, []uint8{250, 252, 254}, ToSlice[uint8](NewRangeStream[uint8](250, 255, 2)))
+}
+
+func TestRangeStreamOperations(t *testing.T) {
+	evens := NewRangeStream(0, 10, 2)
+	result := Intersect[int](evens, NewSliceStream([]int{1, 2, 3, 4}), true)
+	require.EqualValues(t, []int{2, 4}, ToSlice(result))
+
+	require.Panics(t, func() { Union[int](NewRangeStream(0, 10, 2), NewSliceStream([]int{}), false) })
+}
+
+func TestIntersectDense(t *testing.T) {
+	type test struct {
+		a, b   SortedNumbersStream[int]
+		result []int
+	}
+	tests := []test{
+		{NewRangeStream(0, 10, 1), NewRangeStream(5, 15, 1), []int{5, 6, 7, 8, 9}},
+		{NewRangeStream(5, 15, 1), NewRangeStream(0, 7, 1), []int{5, 6}},
+		{NewRangeStream(0, 3, 1), NewRangeStream(3, 6, 1), []int{}},
+		{NewRangeStream(0, 10, 1), NewRangeStream(0, 0, 1), []int{}},
+		{NewRangeStream(0, 10, 1), NewRangeStream(0, 10, 3), []int{0, 3, 6, 9}}, // not dense
+		{NewRangeStream(0, 10, 1), NewSliceStream([]int{2, 4}), []int{2, 4}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(IntersectDense(tt.a, tt.b, true)))
+		})
+	}
+}
+
+func TestIntersectDenseBounds(t *testing.T) {
+	u8 := IntersectDense[uint8](NewRangeStream[uint8](250, 255, 1), newClosedRange[uint8](200, 255, 1), true)
+	require.EqualValues(t, []uint8{250, 251, 252, 253, 254}, ToSlice(u8))
+	u8 = IntersectDense[uint8](newClosedRange[uint8](250, 255, 1), newClosedRange[uint8](253, 255, 1), true)
+	require.EqualValues(t, []uint8{253, 254, 255}, ToSlice(u8))
+	require.IsType(t, &RangeStream[uint8]{}, u8)
+
+	i8 := IntersectDense[int8](newClosedRange[int8](127, -128, -1), newClosedRange[int8](127, 125, -1), false)
+	require.EqualValues(t, []int8{127, 126, 125}, ToSlice(i8))
+	require.False(t, i8.(DirectedStream[int8]).Asc())
+
+	desc := IntersectDense[int](NewRangeStream(10, 0, -1), NewRangeStream(5, -5, -1), false)
+	require.EqualValues(t, []int{5, 4, 3, 2, 1}, ToSlice(desc))
+	require.IsType(t, &RangeStream[int]{}, desc)
+	require.EqualValues(t, []int{}, ToSlice(IntersectDense[int](NewRangeStream(10, 5, -1), NewRangeStream(3, 0, -1), false)))
+
+	require.Panics(t, func() { IntersectDense[int](NewRangeStream(0, 10, 1), NewRangeStream(5, 15, 1), false) })
+}
+
+func TestIntersectDenseIsArithmetic(t *testing.T) {
+	a, b := NewRangeStream(0, 1<<40, 1), NewRangeStream(1<<39, 1<<41, 1)
+	a.Seek(1<<40-2, true)
+	result := IntersectDense[int](a, b, true)
+	require.EqualValues(t, []int{1<<40 - 2, 1<<40 - 1}, ToSlice(result))
+	n, _ := a.Len()
+	require.Equal(t, 2, n) // the operands are not read
+}
@@ -0,0 +1,189 @@
+package sorted_numeric_streams
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/exp/constraints"
+)
+
+// LinesStream streams lines of a sorted text (like the input of Unix `comm`)
+// Lines are compared as Go strings do: byte-wise, so the order is case-sensitive ("B" < "a"),
+// make sure the input is sorted the same way (e.g. `LC_ALL=C sort`)
+type LinesStream struct {
+	scanner *bufio.Scanner
+	err     error
+}
+
+func (s *LinesStream) Next() (item string, ok bool) {
+	if s.scanner.Scan() {
+		return s.scanner.Text(), true
+	}
+	s.err = s.scanner.Err()
+	return "", false
+}
+
+// Err returns the read error, if any, once the stream is drained
+func (s *LinesStream) Err() error { return s.err }
+
+// NewLinesStream returns the stream of lines of r without line endings
+func NewLinesStream(r io.Reader) *LinesStream {
+	return &LinesStream{scanner: bufio.NewScanner(r)}
+}
+
+// RecvStream streams items received one message at a time, e.g. from a gRPC server-streaming RPC,
+// so posting lists of remote shards can be intersected without fetching them first:
+//
+//	postings, _ := client.Postings(ctx, &pb.Term{Term: "letter"})
+//	ids := NewRecvStream(postings.Recv, (*pb.Posting).GetId)
+//
+// The server must send messages sorted by the extracted item in the direction the operation expects,
+// the order is not checked (see CheckDirection). io.EOF ends the stream, other errors stop it and are reported by Err
+type RecvStream[T constraints.Ordered, M any] struct {
+	recv    func() (M, error)
+	extract func(M) T
+	err     error
+	done    bool
+}
+
+func (s *RecvStream[T, M]) Next() (item T, ok bool) {
+	if s.done {
+		return
+	}
+	message, err := s.recv()
+	if err != nil {
+		s.done = true
+		if !errors.Is(err, io.EOF) {
+			s.err = err
+		}
+		return
+	}
+	return s.extract(message), true
+}
+
+// Err returns the receive error, if any, once the stream is drained
+func (s *RecvStream[T, M]) Err() error { return s.err }
+
+// NewRecvStream returns the stream of items extracted from messages returned by recv until it fails or returns io.EOF
+func NewRecvStream[T constraints.Ordered, M any](recv func() (M, error), extract func(M) T) *RecvStream[T, M] {
+	return &RecvStream[T, M]{recv: recv, extract: extract}
+}
+
+// CSVColumnStream streams parsed values of one column of a CSV sorted by that column
+// Reading stops at the first malformed record or parse error, which is reported by Err
+type CSVColumnStream struct {
+	// SkipEmpty makes records with an empty or missing column skipped instead of failing the stream
+	SkipEmpty bool
+
+	reader *csv.Reader
+	source io.Reader
+	column int
+	parse  func(string) (int, error)
+	done   bool
+	err    error
+}
+
+func (s *CSVColumnStream) Next() (item int, ok bool) {
+	for !s.done {
+		record, err := s.reader.Read()
+		if err == io.EOF {
+			s.done = true
+			break
+		} else if err != nil {
+			s.done, s.err = true, err
+			break
+		}
+
+		line, _ := s.reader.FieldPos(0)
+		if s.column >= len(record) || record[s.column] == "" {
+			if s.SkipEmpty {
+				continue
+			}
+			s.done, s.err = true, fmt.Errorf("line %d: column %d is empty", line, s.column)
+			break
+		}
+		if item, err = s.parse(record[s.column]); err != nil {
+			s.done, s.err = true, fmt.Errorf("line %d: %w", line, err)
+			break
+		}
+		return item, true
+	}
+	return 0, false
+}
+
+// Err returns the read or parse error, if any, once the stream is drained
+func (s *CSVColumnStream) Err() error { return s.err }
+
+// Close closes the underlying reader if it is an io.Closer
+func (s *CSVColumnStream) Close() error {
+	s.done = true
+	if c, ok := s.source.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// NewCSVColumnStream returns the stream of values of the column (0-based) of a CSV sorted by it
+// parse converts the field to a number, e.g. strconv.Atoi
+func NewCSVColumnStream(r io.Reader, column int, parse func(string) (int, error)) *CSVColumnStream {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // records with a missing column are handled by SkipEmpty
+	reader.ReuseRecord = true
+	return &CSVColumnStream{
+		reader: reader,
+		source: r,
+		column: column,
+		parse:  parse,
+	}
+}
+
+// ParseStream streams values parsed from lines delivered over a channel, so reading is decoupled from parsing
+// and any reader can feed it. Malformed lines stop the stream by default, the error is reported by Err
+type ParseStream[T constraints.Ordered] struct {
+	// SkipMalformed makes lines failing to parse skipped instead of stopping the stream
+	SkipMalformed bool
+	// OnMalformed, if set, decides for every line failing to parse (1-based line number): true skips it, false stops the stream
+	OnMalformed func(line int, text string, err error) (skip bool)
+
+	lines <-chan string
+	parse func(string) (T, error)
+	line  int
+	done  bool
+	err   error
+}
+
+func (s *ParseStream[T]) Next() (item T, ok bool) {
+	for !s.done {
+		text, more := <-s.lines
+		if !more {
+			s.done = true
+			break
+		}
+		s.line++
+		item, err := s.parse(text)
+		if err == nil {
+			return item, true
+		}
+		skip := s.SkipMalformed
+		if s.OnMalformed != nil {
+			skip = s.OnMalformed(s.line, text, err)
+		}
+		if !skip {
+			s.done, s.err = true, fmt.Errorf("line %d: %w", s.line, err)
+		}
+	}
+	return item, false
+}
+
+// Err returns the parse error which stopped the stream, if any, once the stream is drained
+// A stopped stream reads no more lines, so the producer should not block on sending them (e.g. use a buffer or a context)
+func (s *ParseStream[T]) Err() error { return s.err }
+
+// NewParseStream returns the stream of values parsed from lines, the stream is drained once lines is closed
+// parse converts a line to a value, e.g. strconv.Atoi
+func NewParseStream[T constraints.Ordered](lines <-chan string, parse func(string) (T, error)) *ParseStream[T] {
+	return &ParseStream[T]{lines: lines, parse: parse}
+}
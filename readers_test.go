@@ -0,0 +1,165 @@
+package sorted_numeric_streams
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinesStream(t *testing.T) {
+	a := NewLinesStream(strings.NewReader("apple\nbanana\ncherry\n"))
+	b := NewLinesStream(strings.NewReader("banana\ncherry\ndate"))
+	require.EqualValues(t, []string{"banana", "cherry"}, ToSlice[string](Intersect[string](a, b, true)))
+	require.NoError(t, a.Err())
+
+	// byte order: upper case goes first
+	a = NewLinesStream(strings.NewReader("B\na\n"))
+	b = NewLinesStream(strings.NewReader("A\nb\n"))
+	require.EqualValues(t, []string{"A", "B", "a", "b"}, ToSlice[string](Union[string](a, b, true)))
+}
+
+func TestLinesStreamError(t *testing.T) {
+	failure := errors.New("read failure")
+	s := NewLinesStream(io.MultiReader(strings.NewReader("a\n"), &failingReader{failure}))
+	require.EqualValues(t, []string{"a"}, ToSlice[string](s))
+	require.ErrorIs(t, s.Err(), failure)
+}
+
+type failingReader struct{ err error }
+
+func (r *failingReader) Read([]byte) (int, error) { return 0, r.err }
+
+func TestCSVColumnStream(t *testing.T) {
+	a := NewCSVColumnStream(strings.NewReader("1,a\n3,b\n5,c\n"), 0, strconv.Atoi)
+	b := NewSliceStream([]int{3, 4, 5})
+	require.EqualValues(t, []int{3, 5}, ToSlice[int](Intersect[int](a, b, true)))
+	require.NoError(t, a.Err())
+}
+
+func TestCSVColumnStreamEmptyPolicy(t *testing.T) {
+	data := "a,1\nb,\nc\nd,4\n"
+
+	s := NewCSVColumnStream(strings.NewReader(data), 1, strconv.Atoi)
+	require.EqualValues(t, []int{1}, ToSlice[int](s))
+	require.ErrorContains(t, s.Err(), "line 2")
+
+	s = NewCSVColumnStream(strings.NewReader(data), 1, strconv.Atoi)
+	s.SkipEmpty = true
+	require.EqualValues(t, []int{1, 4}, ToSlice[int](s))
+	require.NoError(t, s.Err())
+}
+
+func TestCSVColumnStreamParseError(t *testing.T) {
+	s := NewCSVColumnStream(strings.NewReader("1\n2\nx\n4\n"), 0, strconv.Atoi)
+	require.EqualValues(t, []int{1, 2}, ToSlice[int](s))
+	require.ErrorIs(t, s.Err(), strconv.ErrSyntax)
+	require.ErrorContains(t, s.Err(), "line 3")
+}
+
+func TestCSVColumnStreamClose(t *testing.T) {
+	r := &closeRecorder{Reader: strings.NewReader("1\n2\n")}
+	s := NewCSVColumnStream(r, 0, strconv.Atoi)
+	var closer io.Closer = s
+	require.NoError(t, closer.Close())
+	require.True(t, r.closed)
+	_, ok := s.Next()
+	require.False(t, ok)
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (r *closeRecorder) Close() error {
+	r.closed = true
+	return nil
+}
+
+func sendLines(lines ...string) <-chan string {
+	ch := make(chan string, len(lines))
+	for _, line := range lines {
+		ch <- line
+	}
+	close(ch)
+	return ch
+}
+
+func TestParseStream(t *testing.T) {
+	s := NewParseStream(sendLines("1", "2", "x", "4"), strconv.Atoi)
+	require.EqualValues(t, []int{1, 2}, ToSlice[int](s))
+	require.ErrorContains(t, s.Err(), "line 3")
+
+	s = NewParseStream(sendLines("1", "2", "4"), strconv.Atoi)
+	require.EqualValues(t, []int{1, 2, 4}, ToSlice[int](s))
+	require.NoError(t, s.Err())
+}
+
+func TestParseStreamMalformedPolicy(t *testing.T) {
+	s := NewParseStream(sendLines("1", "x", "3", "y"), strconv.Atoi)
+	s.SkipMalformed = true
+	require.EqualValues(t, []int{1, 3}, ToSlice[int](s))
+	require.NoError(t, s.Err())
+
+	var malformed []int
+	s = NewParseStream(sendLines("1", "", "3", "x", "5"), strconv.Atoi)
+	s.OnMalformed = func(line int, text string, err error) bool {
+		malformed = append(malformed, line)
+		return text == "" // tolerate empty lines only
+	}
+	require.EqualValues(t, []int{1, 3}, ToSlice[int](s))
+	require.EqualValues(t, []int{2, 4}, malformed)
+	var numErr *strconv.NumError
+	require.True(t, errors.As(s.Err(), &numErr))
+}
+
+// postingsServer imitates the client side of a server-streaming RPC
+type postingsServer struct {
+	messages []*posting
+	err      error // returned once the messages are sent
+}
+
+type posting struct{ id uint64 }
+
+func (p *posting) GetID() uint64 { return p.id }
+
+func (s *postingsServer) Recv() (*posting, error) {
+	if len(s.messages) == 0 {
+		return nil, s.err
+	}
+	m := s.messages[0]
+	s.messages = s.messages[1:]
+	return m, nil
+}
+
+func TestRecvStream(t *testing.T) {
+	shard1 := &postingsServer{messages: []*posting{{1}, {3}, {5}, {8}}, err: io.EOF}
+	shard2 := &postingsServer{messages: []*posting{{3}, {4}, {8}}, err: io.EOF}
+	a := NewRecvStream(shard1.Recv, (*posting).GetID)
+	b := NewRecvStream(shard2.Recv, (*posting).GetID)
+	require.EqualValues(t, []uint64{3, 8}, ToSlice(Intersect[uint64](a, b, true)))
+	require.NoError(t, a.Err())
+
+	empty := NewRecvStream((&postingsServer{err: io.EOF}).Recv, (*posting).GetID)
+	require.EqualValues(t, []uint64{}, ToSlice[uint64](empty))
+	require.NoError(t, empty.Err())
+}
+
+func TestRecvStreamError(t *testing.T) {
+	failure := errors.New("connection reset")
+	server := &postingsServer{messages: []*posting{{1}, {2}}, err: failure}
+	s := NewRecvStream(server.Recv, (*posting).GetID)
+	require.EqualValues(t, []uint64{1, 2}, ToSlice[uint64](s))
+	require.ErrorIs(t, s.Err(), failure)
+	_, ok := s.Next()
+	require.False(t, ok) // the stream stays stopped
+
+	server = &postingsServer{messages: []*posting{{1}, {2}}, err: failure}
+	result, errs := UnionAsync[uint64](NewRecvStream(server.Recv, (*posting).GetID), NewSliceStream([]uint64{3}), true)
+	ToSlice(result)
+	require.ErrorIs(t, <-errs, failure)
+}
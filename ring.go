@@ -0,0 +1,83 @@
+package sorted_numeric_streams
+
+import "sync"
+
+// RingStream is an alternative to ChannelStream for a single producer and a single consumer:
+// items go through a bounded ring buffer guarded by a mutex instead of a channel.
+// Which one is faster depends on the workload and the scheduler, compare them with BenchmarkRingStream
+type RingStream[T any] struct {
+	mu       sync.Mutex
+	notEmpty sync.Cond
+	notFull  sync.Cond
+	items    []T
+	head     int // index of the oldest item
+	n        int // number of buffered items
+	closed   bool
+}
+
+// NewRingStream returns a stream buffering up to capacity items (at least 1)
+func NewRingStream[T any](capacity int) *RingStream[T] {
+	if capacity < 1 {
+		capacity = 1
+	}
+	s := &RingStream[T]{items: make([]T, capacity)}
+	s.notEmpty.L, s.notFull.L = &s.mu, &s.mu
+	return s
+}
+
+// Next blocks until an item is pushed, it returns false once the stream is closed and drained
+func (s *RingStream[T]) Next() (item T, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.n == 0 && !s.closed {
+		s.notEmpty.Wait()
+	}
+	if s.n == 0 {
+		return item, false
+	}
+	var empty T
+	item, s.items[s.head] = s.items[s.head], empty // so the buffer does not keep the item alive
+	s.head = (s.head + 1) % len(s.items)
+	s.n--
+	s.notFull.Signal()
+	return item, true
+}
+
+// Push blocks while the buffer is full, pushing to a closed stream panics like sending to a closed channel does
+func (s *RingStream[T]) Push(item T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.n == len(s.items) && !s.closed {
+		s.notFull.Wait()
+	}
+	s.put(item)
+}
+
+// TryPush adds the item only if the buffer has space, it returns false otherwise (see ChannelStream.TryPush)
+func (s *RingStream[T]) TryPush(item T) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.n == len(s.items) && !s.closed {
+		return false
+	}
+	s.put(item)
+	return true
+}
+
+func (s *RingStream[T]) put(item T) {
+	if s.closed {
+		panic("push to a closed ring stream")
+	}
+	s.items[(s.head+s.n)%len(s.items)] = item
+	s.n++
+	s.notEmpty.Signal()
+}
+
+// Close ends the stream once buffered items are read, it is idempotent
+func (s *RingStream[T]) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.notEmpty.Broadcast()
+	s.notFull.Broadcast()
+}
@@ -0,0 +1,72 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingStream(t *testing.T) {
+	s := NewRingStream[int](2)
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.Push(i)
+		}
+		s.Close()
+	}()
+	require.EqualValues(t, ToSlice(NewRangeStream(0, 100, 1)), ToSlice[int](s))
+
+	_, ok := s.Next()
+	require.False(t, ok)
+	require.NotPanics(t, s.Close)
+	require.Panics(t, func() { s.Push(1) })
+}
+
+func TestRingStreamTryPush(t *testing.T) {
+	s := NewRingStream[int](1)
+	require.True(t, s.TryPush(1))
+	require.False(t, s.TryPush(2)) // full
+	item, ok := s.Next()
+	require.True(t, ok)
+	require.Equal(t, 1, item)
+	require.True(t, s.TryPush(2))
+	s.Close()
+	require.EqualValues(t, []int{2}, ToSlice[int](s)) // buffered items outlive Close
+}
+
+// pushStream is a stream filled by a producer goroutine
+type pushStream interface {
+	SortedNumbersStream[int]
+	Push(int)
+	Close()
+}
+
+// BenchmarkRingStream compares passing items through RingStream and ChannelStream with the same buffer
+func BenchmarkRingStream(b *testing.B) {
+	const items, capacity = 10_000, 64
+	streams := []struct {
+		name string
+		make func() pushStream
+	}{
+		{"ring", func() pushStream {
+			return NewRingStream[int](capacity)
+		}},
+		{"channel", func() pushStream {
+			return &ChannelStream[int]{pipe: make(chan int, capacity)}
+		}},
+	}
+	for _, stream := range streams {
+		b.Run(stream.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				s := stream.make()
+				go func() {
+					for j := 0; j < items; j++ {
+						s.Push(j)
+					}
+					s.Close()
+				}()
+				ToSlice[int](s)
+			}
+		})
+	}
+}
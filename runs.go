@@ -0,0 +1,68 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// Run is a value repeated Count times in a row
+type Run[T any] struct {
+	Value T
+	Count int
+}
+
+// RunLengthStream collapses runs of equal items of a sorted stream with duplicates, e.g. [1,1,2] gives [{1 2} {2 1}]
+// It is a "group by" for sorted data: over Merge of several streams it counts occurrences of every item across them
+func RunLengthStream[T comparable](stream SortedNumbersStream[T]) SortedNumbersStream[Run[T]] {
+	return &runLengthStream[T]{stream: orEmpty(stream)}
+}
+
+type runLengthStream[T comparable] struct {
+	stream  SortedNumbersStream[T]
+	next    T // the first item of the next run
+	hasNext bool
+	started bool
+}
+
+func (s *runLengthStream[T]) Next() (run Run[T], ok bool) {
+	if !s.started {
+		s.next, s.hasNext = s.stream.Next()
+		s.started = true
+	}
+	if !s.hasNext {
+		return
+	}
+	run = Run[T]{Value: s.next}
+	for s.hasNext && s.next == run.Value {
+		run.Count++
+		s.next, s.hasNext = s.stream.Next()
+	}
+	return run, true
+}
+
+// DedupWithin collapses items within `within` of the last emitted item into it, e.g. [1,2,3,10] within 2 gives [1,10],
+// to deduplicate noisy sorted timestamps. Distances are measured from the emitted representative,
+// so a chain of close items does not drift: [1,2,3,4] within 2 gives [1,4]
+func DedupWithin[T constraints.Integer](stream SortedNumbersStream[T], within T) SortedNumbersStream[T] {
+	return keepDirection[T](stream, &dedupWithinStream[T]{stream: orEmpty(stream), within: within})
+}
+
+type dedupWithinStream[T constraints.Integer] struct {
+	stream  SortedNumbersStream[T]
+	within  T
+	last    T
+	started bool
+}
+
+func (s *dedupWithinStream[T]) Next() (item T, ok bool) {
+	for {
+		if item, ok = s.stream.Next(); !ok {
+			return
+		}
+		lo, hi := s.last, item // both directions, the distance is measured in uint64 so it does not overflow T
+		if hi < lo {
+			lo, hi = hi, lo
+		}
+		if !s.started || s.within < 0 || distance(lo, hi) > uint64(s.within) {
+			s.last, s.started = item, true
+			return item, true
+		}
+	}
+}
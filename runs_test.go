@@ -0,0 +1,71 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunLengthStream(t *testing.T) {
+	type test struct {
+		items  []int
+		result []Run[int]
+	}
+	tests := []test{
+		{[]int{1, 1, 2, 3, 3, 3}, []Run[int]{{1, 2}, {2, 1}, {3, 3}}},
+		{[]int{1, 2}, []Run[int]{{1, 1}, {2, 1}}},
+		{[]int{5, 5}, []Run[int]{{5, 2}}},
+		{[]int{}, []Run[int]{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(RunLengthStream[int](NewSliceStream(tt.items))))
+		})
+	}
+}
+
+func TestRunLengthStreamOfMerge(t *testing.T) {
+	merged := Merge([]SortedNumbersStream[int]{
+		NewSliceStream([]int{1, 2, 3}),
+		NewSliceStream([]int{2, 3}),
+		NewSliceStream([]int{3}),
+	}, true)
+	require.EqualValues(t, []Run[int]{{1, 1}, {2, 2}, {3, 3}}, ToSlice(RunLengthStream(merged)))
+}
+
+func TestDedupWithin(t *testing.T) {
+	type test struct {
+		items  []int
+		within int
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 10}, 2, []int{1, 10}},
+		{[]int{1, 2, 3, 4}, 2, []int{1, 4}},
+		{[]int{1, 1, 2, 2}, 0, []int{1, 2}},
+		{[]int{10, 9, 5, 4}, 1, []int{10, 5}},
+		{[]int{}, 5, []int{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, tt.result, ToSlice(DedupWithin[int](NewSliceStream(tt.items), tt.within)))
+		})
+	}
+
+	unsigned := DedupWithin[uint8](NewSliceStream([]uint8{250, 200, 3, 1}), 5)
+	require.EqualValues(t, []uint8{250, 200, 3}, ToSlice(unsigned))
+
+	narrow := DedupWithin[int8](NewSliceStream([]int8{-100, 100}), 2)
+	require.EqualValues(t, []int8{-100, 100}, ToSlice(narrow))
+	narrow = DedupWithin[int8](NewSliceStream([]int8{-128, -127, 127}), 127)
+	require.EqualValues(t, []int8{-128, 127}, ToSlice(narrow))
+
+	extremes := DedupWithin[int64](NewSliceStream([]int64{math.MinInt64, math.MaxInt64}), 1)
+	require.EqualValues(t, []int64{math.MinInt64, math.MaxInt64}, ToSlice(extremes))
+	extremes = DedupWithin[int64](NewSliceStream([]int64{math.MaxInt64, math.MinInt64}), math.MaxInt64)
+	require.EqualValues(t, []int64{math.MaxInt64, math.MinInt64}, ToSlice(extremes))
+}
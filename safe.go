@@ -0,0 +1,23 @@
+package sorted_numeric_streams
+
+import "sync"
+
+// SafeStream lets several goroutines pull items from one stream, work-stealing style: every item goes to one of them.
+// ChannelStream (so results of Union/Intersect/Diff with the default backend) and Tee readers are already safe,
+// while SliceStream, RangeStream, HeapStream, DecodedStream and pull-based operations (Compose) are not.
+// Items are handed out in order, but consumers see them interleaved
+type SafeStream[T any] struct {
+	mu     sync.Mutex
+	stream SortedNumbersStream[T]
+}
+
+func (s *SafeStream[T]) Next() (T, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stream.Next()
+}
+
+// NewSafeStream guards Next of the stream with a mutex
+func NewSafeStream[T any](stream SortedNumbersStream[T]) *SafeStream[T] {
+	return &SafeStream[T]{stream: orEmpty(stream)}
+}
@@ -0,0 +1,37 @@
+package sorted_numeric_streams
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSafeStream(t *testing.T) {
+	stream := NewSafeStream[int](NewRangeStream(0, 10_000, 1))
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		consumed []int
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var mine []int
+			for item, ok := stream.Next(); ok; item, ok = stream.Next() {
+				mine = append(mine, item)
+			}
+			require.True(t, sort.IntsAreSorted(mine)) // every consumer sees items in order
+			mu.Lock()
+			consumed = append(consumed, mine...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	sort.Ints(consumed)
+	require.EqualValues(t, ToSlice[int](NewRangeStream(0, 10_000, 1)), consumed) // every item is consumed once
+}
@@ -0,0 +1,67 @@
+package sorted_numeric_streams
+
+import "fmt"
+
+// Sample emits every Nth item of the stream starting with the first one, e.g. every=3 over [1..10] gives [1,4,7,10]
+// Useful for cheap approximate analytics over huge sorted sets: the result size times every estimates the cardinality
+func Sample[T any](stream SortedNumbersStream[T], every int) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	if every < 1 {
+		panic(fmt.Sprintf("sample step must be positive, got %d", every))
+	}
+	return keepDirection[T](stream, &sampleStream[T]{stream: stream, every: every})
+}
+
+type sampleStream[T any] struct {
+	stream  SortedNumbersStream[T]
+	every   int
+	started bool
+}
+
+// Next skips the rest of the previous step before reading the item, so the item is returned
+// without waiting for the items after it (e.g. of a blocking source) and a reader stopping early does not consume them
+func (s *sampleStream[T]) Next() (item T, ok bool) {
+	if s.started {
+		for i := 1; i < s.every; i++ {
+			if _, ok = s.stream.Next(); !ok {
+				return
+			}
+		}
+	}
+	s.started = true
+	return s.stream.Next()
+}
+
+// Downsample emits about target evenly spaced items of a stream with total items, in one pass without buffering
+// e.g. to plot a sorted series at screen resolution. The first item is always emitted.
+// All items are emitted when target >= total, none when target < 1
+func Downsample[T any](stream SortedNumbersStream[T], total, target int) SortedNumbersStream[T] {
+	stream = orEmpty(stream)
+	if target >= total {
+		return stream
+	}
+	if target < 1 {
+		return keepDirection[T](stream, emptyStream[T]{})
+	}
+	return keepDirection[T](stream, &downsampleStream[T]{stream: stream, total: total, target: target})
+}
+
+type downsampleStream[T any] struct {
+	stream        SortedNumbersStream[T]
+	total, target int
+	pos, emitted  int
+}
+
+func (s *downsampleStream[T]) Next() (item T, ok bool) {
+	if s.emitted == s.target {
+		return
+	}
+	want := s.emitted * s.total / s.target // position of the next emitted item
+	for ; s.pos <= want; s.pos++ {
+		if item, ok = s.stream.Next(); !ok {
+			return
+		}
+	}
+	s.emitted++
+	return item, true
+}
@@ -0,0 +1,84 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSample(t *testing.T) {
+	type test struct {
+		items  []int
+		every  int
+		result []int
+	}
+	tests := []test{
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, 3, []int{1, 4, 7, 10}},
+		{[]int{1, 2, 3, 4, 5, 6, 7, 8, 9}, 3, []int{1, 4, 7}},
+		{[]int{1, 2, 3}, 1, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, 5, []int{1}},
+		{[]int{}, 2, []int{}},
+	}
+
+	for _, tt := range tests {
+		require.EqualValues(t, tt.result, ToSlice(Sample[int](NewSliceStream(tt.items), tt.every)))
+	}
+}
+
+func TestSampleDoesNotReadAhead(t *testing.T) {
+	stream, history := Record[int](NewRangeStream(0, 10, 1))
+	s := Sample[int](stream, 3)
+	item, _ := s.Next()
+	require.Equal(t, 0, item)
+	require.Len(t, history(), 1)
+	item, _ = s.Next()
+	require.Equal(t, 3, item)
+	require.Len(t, history(), 4)
+}
+
+func TestSampleValidation(t *testing.T) {
+	require.Panics(t, func() { Sample[int](NewSliceStream([]int{1}), 0) })
+
+	desc := Sample[int](WithDirection[int](NewSliceStream([]int{3, 2, 1}), false), 2)
+	require.False(t, desc.(DirectedStream[int]).Asc())
+}
+
+func TestDownsample(t *testing.T) {
+	type test struct {
+		items  []int
+		target int
+		result []int
+	}
+	tests := []test{
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 5, []int{0, 2, 4, 6, 8}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 3, []int{0, 3, 6}},
+		{[]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 1, []int{0}},
+		{[]int{0, 1, 2}, 3, []int{0, 1, 2}},
+		{[]int{0, 1, 2}, 10, []int{0, 1, 2}},
+		{[]int{0, 1, 2}, 0, []int{}},
+	}
+
+	for _, tt := range tests {
+		result := Downsample[int](NewSliceStream(tt.items), len(tt.items), tt.target)
+		require.EqualValues(t, tt.result, ToSlice(result))
+	}
+}
+
+func TestDownsampleShortStream(t *testing.T) {
+	// total overestimates the stream, emit what is there
+	result := Downsample[int](NewSliceStream([]int{0, 1, 2, 3}), 10, 5)
+	require.EqualValues(t, []int{0, 2}, ToSlice(result))
+
+	// total underestimates the stream, stop at target
+	result = Downsample[int](NewSliceStream([]int{0, 1, 2, 3, 4, 5}), 4, 2)
+	require.EqualValues(t, []int{0, 2}, ToSlice(result))
+}
+
+func TestSampleNilStream(t *testing.T) {
+	require.EqualValues(t, []int{}, ToSlice(Sample[int](nil, 2)))
+}
+
+func TestDownsampleNilStream(t *testing.T) {
+	require.EqualValues(t, []int{}, ToSlice(Downsample[int](nil, 10, 5)))
+	require.EqualValues(t, []int{}, ToSlice(Downsample[int](nil, 10, 20)))
+}
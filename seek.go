@@ -0,0 +1,145 @@
+package sorted_numeric_streams
+
+import (
+	"math/bits"
+
+	"golang.org/x/exp/constraints"
+)
+
+// IntersectSmart is Intersect which iterates the smaller operand and seeks in the larger one
+// when both are Sized, the larger one is Seekable and the size ratio makes seeking cheaper than a linear merge.
+// Otherwise, it falls back to Intersect.
+func IntersectSmart[T constraints.Ordered](stream1, stream2 SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	stream1, stream2 = orEmpty(stream1), orEmpty(stream2)
+	mustMatchDirection(asc, stream1, stream2)
+
+	n1, known1 := sizeOf(stream1)
+	n2, known2 := sizeOf(stream2)
+	if !known1 || !known2 {
+		return Intersect(stream1, stream2, asc)
+	}
+	probe, large, nProbe, nLarge := stream1, stream2, n1, n2
+	if n2 < n1 {
+		probe, large, nProbe, nLarge = stream2, stream1, n2, n1
+	}
+	seekable, ok := large.(Seekable[T])
+	// every seek costs about log(nLarge) comparisons, the linear merge costs nProbe+nLarge
+	if !ok || nProbe == 0 || nProbe*bits.Len(uint(nLarge)) >= nLarge {
+		return Intersect(stream1, stream2, asc)
+	}
+
+	result := NewChannelStream[T]()
+	go func() {
+		defer result.closeOnPanic()
+		seekIntersect(probe, seekable, asc, result.Push)
+		result.Close()
+	}()
+	return &directedStream[T]{result, asc}
+}
+
+// seekIntersect emits probe items found in large, seeking large to every probe item
+func seekIntersect[T constraints.Ordered](probe SortedNumbersStream[T], large Seekable[T], asc bool, emit func(T)) {
+	var (
+		held    T // an item read from large which went after a probe item
+		hasHeld bool
+	)
+	for {
+		p, ok := probe.Next()
+		if !ok {
+			return
+		}
+		if hasHeld {
+			if c := compareOrdered(held, p); c == 0 {
+				emit(p)
+				hasHeld = false
+				continue
+			} else if asc && c > 0 || !asc && c < 0 {
+				continue // held goes after p, so p is not in large
+			}
+		}
+		large.Seek(p, asc)
+		if held, hasHeld = large.Next(); !hasHeld {
+			return // nothing else to match
+		}
+		if compareOrdered(held, p) == 0 {
+			emit(p)
+			hasHeld = false
+		}
+	}
+}
+
+func sizeOf[T any](stream SortedNumbersStream[T]) (int, bool) {
+	if s, ok := stream.(Sized[T]); ok {
+		return s.Len()
+	}
+	return 0, false
+}
+
+// ResumableIntersect is Intersect of the operands after from: both are sought past it, so a long job interrupted
+// after emitting from (see WithCheckpoint) resumes with the next item without re-reading the processed prefix.
+// For the first run pass from going before every item (e.g. math.MinInt for asc ints)
+func ResumableIntersect[T constraints.Ordered](a, b Seekable[T], from T, asc bool, opts ...Option) SortedNumbersStream[T] {
+	skip := func(s Seekable[T]) SortedNumbersStream[T] {
+		return keepDirection[T](s, &afterStream[T]{stream: s, from: from, asc: asc})
+	}
+	return Intersect(skip(a), skip(b), asc, opts...)
+}
+
+// WithCheckpoint passes the last item read from the stream to save every `every` items and once more when
+// the stream is drained, so an interrupted job can be resumed from it (see ResumableIntersect):
+//
+//	result := WithCheckpoint(ResumableIntersect(a, b, last, true), 1000, store)
+//
+// save runs in the goroutine reading the stream, after the item is read. The direction of the stream is kept
+func WithCheckpoint[T any](stream SortedNumbersStream[T], every int, save func(last T)) SortedNumbersStream[T] {
+	if every < 1 {
+		every = 1
+	}
+	return keepDirection(stream, &checkpointStream[T]{stream: orEmpty(stream), every: every, save: save})
+}
+
+// afterStream skips items up to and including from on the first read
+type afterStream[T constraints.Ordered] struct {
+	stream  Seekable[T]
+	from    T
+	asc     bool
+	started bool
+}
+
+func (s *afterStream[T]) Next() (item T, ok bool) {
+	if !s.started {
+		s.started = true
+		s.stream.Seek(s.from, s.asc)
+		for {
+			if item, ok = s.stream.Next(); !ok || compareOrdered(item, s.from) != 0 {
+				return
+			}
+		}
+	}
+	return s.stream.Next()
+}
+
+// checkpointStream saves the last read item every `every` items and once the stream is drained
+type checkpointStream[T any] struct {
+	stream SortedNumbersStream[T]
+	every  int
+	save   func(last T)
+	read   int
+	last   T
+	saved  bool // the last item is saved
+}
+
+func (s *checkpointStream[T]) Next() (item T, ok bool) {
+	if item, ok = s.stream.Next(); ok {
+		s.read++
+		s.last, s.saved = item, false
+		if s.read%s.every == 0 {
+			s.save(item)
+			s.saved = true
+		}
+	} else if s.read > 0 && !s.saved {
+		s.save(s.last)
+		s.saved = true
+	}
+	return
+}
@@ -0,0 +1,236 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSliceStreamSeek(t *testing.T) {
+	type test struct {
+		items     []int
+		skip      int // items read before seeking
+		target    int
+		asc       bool
+		remaining []int
+	}
+	tests := []test{
+		{[]int{}, 0, 1, true, []int{}},
+		{[]int{1, 2, 3}, 0, 0, true, []int{1, 2, 3}},
+		{[]int{1, 2, 3}, 0, 2, true, []int{2, 3}},
+		{[]int{1, 2, 3}, 0, 4, true, []int{}},
+		{[]int{1, 3, 5, 7, 9, 11, 13, 15, 17}, 0, 14, true, []int{15, 17}},
+		{[]int{1, 3, 5, 7, 9, 11, 13, 15, 17}, 0, 17, true, []int{17}},
+		{[]int{1, 3, 5, 7, 9, 11, 13, 15, 17}, 2, 2, true, []int{5, 7, 9, 11, 13, 15, 17}}, // never goes back
+		{[]int{1, 1, 1, 2}, 0, 1, true, []int{1, 1, 1, 2}},
+		{[]int{9, 7, 5, 3, 1}, 0, 4, false, []int{3, 1}},
+		{[]int{9, 7, 5, 3, 1}, 1, 9, false, []int{7, 5, 3, 1}},
+		{[]int{9, 7, 5, 3, 1}, 0, 0, false, []int{}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := NewSliceStream(tt.items)
+			for j := 0; j < tt.skip; j++ {
+				s.Next()
+			}
+			s.Seek(tt.target, tt.asc)
+			require.EqualValues(t, tt.remaining, ToSlice[int](s))
+		})
+	}
+}
+
+func TestRangeStreamSeek(t *testing.T) {
+	s := NewRangeStream(0, 20, 3) // 0 3 6 9 12 15 18
+	s.Seek(-5, true)
+	item, _ := s.Next()
+	require.Equal(t, 0, item)
+	s.Seek(7, true)
+	item, _ = s.Next()
+	require.Equal(t, 9, item)
+	s.Seek(12, true)
+	item, _ = s.Next()
+	require.Equal(t, 12, item)
+	s.Seek(3, true) // never goes back
+	item, _ = s.Next()
+	require.Equal(t, 15, item)
+	s.Seek(100, true)
+	require.EqualValues(t, []int{}, ToSlice[int](s))
+}
+
+func TestRangeStreamSeekNarrowTypes(t *testing.T) {
+	u8 := NewRangeStream[uint8](5, 10, 1)
+	u8.Seek(2, true)
+	require.EqualValues(t, []uint8{5, 6, 7, 8, 9}, ToSlice[uint8](u8))
+
+	u8 = NewRangeStream[uint8](0, 255, 50) // 0 50 100 150 200 250
+	u8.Seek(201, true)
+	require.EqualValues(t, []uint8{250}, ToSlice[uint8](u8))
+
+	u32 := NewRangeStream[uint32](5, 10, 1)
+	u32.Seek(2, true)
+	require.EqualValues(t, []uint32{5, 6, 7, 8, 9}, ToSlice[uint32](u32))
+
+	i8 := NewRangeStream[int8](100, 120, 1)
+	i8.Seek(-100, true)
+	n, _ := i8.Len()
+	require.Equal(t, 20, n)
+	i8.Seek(118, true)
+	require.EqualValues(t, []int8{118, 119}, ToSlice[int8](i8))
+
+	wide := NewRangeStream[int8](-100, 120, 1) // the bounds are further apart than the largest int8
+	n, _ = wide.Len()
+	require.Equal(t, 220, n)
+	wide.Seek(119, true)
+	require.EqualValues(t, []int8{119}, ToSlice[int8](wide))
+
+	down := NewRangeStream[int8](120, -128, -128) // 120 -8
+	down.Seek(0, false)
+	require.EqualValues(t, []int8{-8}, ToSlice[int8](down))
+}
+
+func TestDescendingRangeStreamSeek(t *testing.T) {
+	type test struct {
+		from, to, step int
+		seeks          []int // targets of consecutive seeks
+		result         []int
+	}
+	tests := []test{
+		{20, 0, -3, []int{12}, []int{11, 8, 5, 2}},
+		{20, 0, -3, []int{11, 15}, []int{11, 8, 5, 2}},
+		{20, 0, -3, []int{-1}, []int{}},
+		{20, 0, -3, []int{25}, []int{20, 17, 14, 11, 8, 5, 2}},
+	}
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			s := NewRangeStream(tt.from, tt.to, tt.step)
+			for _, target := range tt.seeks {
+				s.Seek(target, false)
+			}
+			require.EqualValues(t, tt.result, ToSlice[int](s))
+		})
+	}
+
+	require.Panics(t, func() { NewRangeStream(20, 0, -3).Seek(5, true) })
+	require.Panics(t, func() { NewRangeStream(0, 20, 3).Seek(5, false) })
+}
+
+func TestDescendingRangeIntersectSmart(t *testing.T) {
+	sparse := []int{999_999, 500_001, 500_000, 1_234, 7, 0}
+	result := IntersectSmart[int](NewRangeStream(999_999, -1, -1), NewSliceStream(sparse), false)
+	require.False(t, result.(DirectedStream[int]).Asc())
+	require.EqualValues(t, sparse, ToSlice(result))
+
+	evens := NewRangeStream(1_000_000, -1, -2)
+	result = IntersectSmart[int](evens, NewSliceStream(sparse), false)
+	require.EqualValues(t, []int{500_000, 1_234, 0}, ToSlice(result))
+	n, _ := evens.Len()
+	require.Equal(t, 0, n)
+
+	require.Panics(t, func() { Union[int](NewRangeStream(10, 0, -1), NewSliceStream([]int{}), true) })
+}
+
+func TestIntersectSmart(t *testing.T) {
+	large := make([]int, 1000)
+	for i := range large {
+		large[i] = i * 2
+	}
+	largeDesc := make([]int, len(large))
+	for i := range large {
+		largeDesc[i] = large[len(large)-1-i]
+	}
+
+	type test struct {
+		a, b []int
+		asc  bool
+	}
+	tests := []test{
+		{[]int{}, large, true},
+		{[]int{3, 4, 5, 1998, 2000}, large, true},
+		{large, []int{-1, 0, 1, 2, 100, 101, 1998}, true},
+		{[]int{4, 4, 6}, large, true}, // repeated items
+		{[]int{2000, 1998, 7, 4, 0}, largeDesc, false},
+		{largeDesc, []int{100, 98, 97}, false},
+		{[]int{1, 2, 3}, []int{2, 3, 4}, true}, // similar sizes fall back to merging
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			expected := ToSlice(Intersect[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))
+			actual := ToSlice(IntersectSmart[int](NewSliceStream(tt.a), NewSliceStream(tt.b), tt.asc))
+			require.EqualValues(t, expected, actual)
+		})
+	}
+
+	// ranges are seekable too
+	result := IntersectSmart[int](NewSliceStream([]int{5, 10, 11, 500}), NewRangeStream(0, 1000, 5), true)
+	require.EqualValues(t, []int{5, 10, 500}, ToSlice(result))
+}
+
+func TestIntersectSmartNaN(t *testing.T) {
+	large := []float64{math.NaN()}
+	for i := 0; i < 100; i++ {
+		large = append(large, float64(i))
+	}
+	result := IntersectSmart[float64](NewSliceStream([]float64{math.NaN(), 5, 200}), NewSliceStream(large), true)
+	items := ToSlice(result)
+	require.Len(t, items, 2)
+	require.True(t, math.IsNaN(items[0]))
+	require.Equal(t, 5.0, items[1])
+}
+
+func BenchmarkIntersectSmart(b *testing.B) {
+	for _, ratio := range []int{10, 1000} {
+		large, _ := benchmarkOperands("identical", true)
+		small := make([]int, len(large)/ratio)
+		for i := range small {
+			small[i] = i * ratio
+		}
+		b.Run(fmt.Sprintf("1:%d merge", ratio), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToSlice(Intersect[int](NewSliceStream(small), NewSliceStream(large), true))
+			}
+		})
+		b.Run(fmt.Sprintf("1:%d smart", ratio), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToSlice(IntersectSmart[int](NewSliceStream(small), NewSliceStream(large), true))
+			}
+		})
+	}
+}
+
+func TestResumableIntersect(t *testing.T) {
+	newOperands := func() (Seekable[int], Seekable[int]) {
+		return NewRangeStream(0, 100, 2), NewRangeStream(0, 100, 3)
+	}
+
+	// the first run is interrupted after a few items
+	var checkpoint int
+	a, b := newOperands()
+	first := WithCheckpoint(ResumableIntersect(a, b, -1, true), 2, func(last int) { checkpoint = last })
+	for i := 0; i < 5; i++ {
+		first.Next()
+	}
+	require.Equal(t, 18, checkpoint) // items 0, 6, 12, 18 were saved, 24 was read after the last checkpoint
+
+	var checkpoints []int
+	a, b = newOperands()
+	resumed := WithCheckpoint(ResumableIntersect(a, b, checkpoint, true), 4, func(last int) { checkpoints = append(checkpoints, last) })
+	require.True(t, resumed.(DirectedStream[int]).Asc())
+	require.EqualValues(t, []int{24, 30, 36, 42, 48, 54, 60, 66, 72, 78, 84, 90, 96}, ToSlice(resumed))
+	require.EqualValues(t, []int{42, 66, 90, 96}, checkpoints) // the final one marks the job done
+}
+
+func TestResumableIntersectDesc(t *testing.T) {
+	a := NewSliceStream([]int{9, 7, 5, 5, 3, 1})
+	b := NewSliceStream([]int{8, 7, 5, 5, 1})
+	require.EqualValues(t, []int{1}, ToSlice(ResumableIntersect(a, b, 5, false)))
+}
+
+func TestResumableIntersectNaN(t *testing.T) {
+	a := NewSliceStream([]float64{math.NaN(), math.NaN(), 1, 2})
+	b := NewSliceStream([]float64{math.NaN(), 2})
+	require.EqualValues(t, []float64{2}, ToSlice(ResumableIntersect[float64](a, b, math.NaN(), true)))
+}
@@ -0,0 +1,64 @@
+package sorted_numeric_streams
+
+import (
+	"iter"
+	"slices"
+
+	"golang.org/x/exp/constraints"
+)
+
+// FromSlice returns the asc stream of slice items, like NewSliceStream.
+// If sorted is false, a sorted copy of the slice is streamed and the slice itself is left untouched
+func FromSlice[T constraints.Ordered](s []T, sorted bool) *SliceStream[T] {
+	if !sorted {
+		s = slices.Clone(s)
+		slices.Sort(s)
+	}
+	return NewSliceStream(s)
+}
+
+// FromSortedSeq reads items of a sorted iterator (e.g. slices.Values or maps.Keys piped through slices.Sorted)
+// The iterator is resumed on every Next and released once it is exhausted,
+// a stream abandoned before that keeps the iterator suspended (see iter.Pull)
+func FromSortedSeq[T any](seq iter.Seq[T]) SortedNumbersStream[T] {
+	next, stop := iter.Pull(seq)
+	return &seqStream[T]{next: next, stop: stop}
+}
+
+type seqStream[T any] struct {
+	next func() (T, bool)
+	stop func()
+}
+
+func (s *seqStream[T]) Next() (item T, ok bool) {
+	if item, ok = s.next(); !ok {
+		s.stop()
+	}
+	return
+}
+
+// NewSortingStream streams items of an unsorted source in order, so it can be an operand of set operations.
+// It is NOT lazy: the first Next drains the whole source into memory and sorts it
+func NewSortingStream[T constraints.Ordered](src SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	return &sortingStream[T]{src: orEmpty(src), asc: asc}
+}
+
+type sortingStream[T constraints.Ordered] struct {
+	src    SortedNumbersStream[T]
+	asc    bool
+	sorted *SliceStream[T]
+}
+
+func (s *sortingStream[T]) Next() (T, bool) {
+	if s.sorted == nil {
+		items := AppendTo(nil, s.src)
+		slices.Sort(items)
+		if !s.asc {
+			slices.Reverse(items)
+		}
+		s.sorted = NewSliceStream(items)
+	}
+	return s.sorted.Next()
+}
+
+func (s *sortingStream[T]) Asc() bool { return s.asc }
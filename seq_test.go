@@ -0,0 +1,43 @@
+package sorted_numeric_streams
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromSlice(t *testing.T) {
+	items := []int{3, 1, 2}
+	require.EqualValues(t, []int{1, 2, 3}, ToSlice[int](FromSlice(items, false)))
+	require.EqualValues(t, []int{3, 1, 2}, items) // sorted a copy
+
+	require.EqualValues(t, []int{1, 2}, ToSlice[int](FromSlice([]int{1, 2}, true)))
+}
+
+func TestFromSortedSeq(t *testing.T) {
+	a := FromSortedSeq(slices.Values([]int{1, 3, 5}))
+	b := FromSortedSeq(slices.Values([]int{2, 3}))
+	require.EqualValues(t, []int{1, 2, 3, 5}, ToSlice(Union(a, b, true)))
+
+	empty := FromSortedSeq(slices.Values([]int{}))
+	_, ok := empty.Next()
+	require.False(t, ok)
+	_, ok = empty.Next() // stays drained
+	require.False(t, ok)
+}
+
+func TestNewSortingStream(t *testing.T) {
+	items := rand.New(rand.NewSource(1)).Perm(100)
+	expected := make([]int, len(items))
+	for i := range expected {
+		expected[i] = i
+	}
+	require.EqualValues(t, expected, ToSlice(NewSortingStream[int](NewSliceStream(items), true)))
+
+	desc := NewSortingStream[int](NewSliceStream(items), false)
+	require.EqualValues(t, []int{99, 50, 0}, ToSlice(Intersect(desc, WithDirection[int](NewSliceStream([]int{99, 50, 0}), false), false)))
+
+	require.EqualValues(t, []int{}, ToSlice(NewSortingStream[int](nil, true)))
+}
@@ -0,0 +1,100 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Tee splits the stream into n independent readers, each one sees the full sequence.
+// Items are read from the source once and kept until every reader has consumed them,
+// so memory is bounded by the lag between the fastest and the slowest reader.
+// A reader running ahead pulls new items from the source without holding the others: readers with buffered items
+// read them meanwhile, only readers needing the next item of the source wait for that pull.
+// A reader that is never drained makes the buffer grow up to the whole stream.
+// Readers are safe to use from different goroutines (e.g. as operands of different operations)
+func Tee[T any](stream SortedNumbersStream[T], n int) []SortedNumbersStream[T] {
+	if n < 1 {
+		panic(fmt.Sprintf("tee needs at least one reader, got %d", n))
+	}
+	stream = orEmpty(stream)
+	t := &tee[T]{source: stream, positions: make([]int, n)}
+	t.pulled.L = &t.mu
+	d, directed := stream.(DirectedStream[T])
+	readers := make([]SortedNumbersStream[T], n)
+	for i := range readers {
+		readers[i] = &teeReader[T]{t, i}
+		if directed {
+			readers[i] = &directedStream[T]{readers[i], d.Asc()} // keep the direction visible to operations
+		}
+	}
+	return readers
+}
+
+type tee[T any] struct {
+	mu        sync.Mutex
+	source    SortedNumbersStream[T]
+	drained   bool
+	pulling   bool      // a reader is reading the source outside the lock
+	pulled    sync.Cond // signals the end of a pull
+	buf       []T       // items not yet consumed by every reader
+	base      int       // absolute position of buf[0]
+	positions []int     // absolute position of the next item for each reader
+}
+
+func (t *tee[T]) next(reader int) (item T, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pos := t.positions[reader]
+	for pos == t.base+len(t.buf) { // the reader is ahead of everyone
+		if t.drained {
+			return
+		}
+		if t.pulling { // another reader is getting the item
+			t.pulled.Wait()
+			continue
+		}
+		if item, ok = t.pull(); !ok {
+			t.drained = true
+			return
+		}
+		t.buf = append(t.buf, item)
+	}
+	item = t.buf[pos-t.base]
+	t.positions[reader]++
+
+	if pos == t.base { // the reader may have been the slowest one, release what everyone has read
+		slowest := t.positions[0]
+		for _, p := range t.positions[1:] {
+			if p < slowest {
+				slowest = p
+			}
+		}
+		released := slowest - t.base
+		var zero T
+		for i := 0; i < released; i++ {
+			t.buf[i] = zero // let the garbage collector take released items
+		}
+		t.buf, t.base = t.buf[released:], slowest
+	}
+	return item, true
+}
+
+// pull reads the source without holding the lock, it is held again on return (even if the source panics)
+func (t *tee[T]) pull() (item T, ok bool) {
+	t.pulling = true
+	t.mu.Unlock()
+	defer func() {
+		t.mu.Lock()
+		t.pulling = false
+		t.pulled.Broadcast()
+	}()
+	return t.source.Next()
+}
+
+type teeReader[T any] struct {
+	tee    *tee[T]
+	reader int
+}
+
+func (r *teeReader[T]) Next() (T, bool) { return r.tee.next(r.reader) }
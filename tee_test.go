@@ -0,0 +1,131 @@
+package sorted_numeric_streams
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTee(t *testing.T) {
+	readers := Tee[int](NewSliceStream([]int{1, 2, 3, 4}), 3)
+	a, b, c := readers[0], readers[1], readers[2]
+
+	// interleaved reads
+	v, _ := a.Next()
+	require.Equal(t, 1, v)
+	v, _ = a.Next()
+	require.Equal(t, 2, v)
+	v, _ = b.Next()
+	require.Equal(t, 1, v)
+
+	require.EqualValues(t, []int{3, 4}, ToSlice(a))
+	require.EqualValues(t, []int{2, 3, 4}, ToSlice(b))
+	require.EqualValues(t, []int{1, 2, 3, 4}, ToSlice(c))
+	_, ok := a.Next()
+	require.False(t, ok)
+}
+
+func TestTeeNilStream(t *testing.T) {
+	for _, reader := range Tee[int](nil, 2) {
+		require.EqualValues(t, []int{}, ToSlice(reader))
+	}
+}
+
+func TestTeeBuffersMaxLag(t *testing.T) {
+	readers := Tee[int](NewSliceStream(ToSlice[int](NewRangeStream(0, 100, 1))), 2)
+	tee := readers[0].(*teeReader[int]).tee
+
+	for i := 0; i < 10; i++ {
+		readers[0].Next()
+	}
+	require.Len(t, tee.buf, 10)
+
+	for i := 0; i < 7; i++ {
+		readers[1].Next()
+	}
+	require.Len(t, tee.buf, 3) // items read by both readers are released
+	require.Equal(t, 7, tee.base)
+}
+
+func TestTeeIntoOperations(t *testing.T) {
+	union := Union[int](NewSliceStream([]int{1, 3, 5}), NewSliceStream([]int{2, 3, 6}), true)
+	readers := Tee[int](union, 2)
+
+	// operations read their operands concurrently
+	intersected := Intersect[int](readers[0], NewSliceStream([]int{2, 5, 7}), true)
+	diffed := Diff[int](readers[1], NewSliceStream([]int{1, 6}), true)
+
+	var wg sync.WaitGroup
+	var r1, r2 []int
+	wg.Add(2)
+	go func() { defer wg.Done(); r1 = ToSlice(intersected) }()
+	go func() { defer wg.Done(); r2 = ToSlice(diffed) }()
+	wg.Wait()
+
+	require.EqualValues(t, []int{2, 5}, r1)
+	require.EqualValues(t, []int{2, 3, 5}, r2)
+}
+
+func TestTeeKeepsDirection(t *testing.T) {
+	readers := Tee[int](WithDirection[int](NewSliceStream([]int{3, 2, 1}), false), 2)
+	for _, r := range readers {
+		require.False(t, r.(DirectedStream[int]).Asc())
+	}
+	require.Panics(t, func() { Tee[int](NewSliceStream([]int{1}), 0) })
+}
+
+// gatedStream emits the items, but waits for the gate before the item at index `at`
+type gatedStream struct {
+	items []int
+	at    int
+	gate  chan struct{}
+	read  int
+}
+
+func (s *gatedStream) Next() (int, bool) {
+	if s.read == s.at {
+		<-s.gate
+	}
+	if s.read == len(s.items) {
+		return 0, false
+	}
+	s.read++
+	return s.items[s.read-1], true
+}
+
+func TestTeeReadersDoNotWaitForSlowSource(t *testing.T) {
+	source := &gatedStream{items: []int{1, 2, 3, 4}, at: 2, gate: make(chan struct{})}
+	readers := Tee[int](source, 3)
+	readers[0].Next()
+	readers[0].Next()
+
+	ahead := make(chan []int)
+	go func() { ahead <- ToSlice(readers[0]) }() // blocks on the source
+
+	v1, _ := readers[1].Next() // buffered items are read while the source is blocked
+	v2, _ := readers[1].Next()
+	require.EqualValues(t, []int{1, 2}, []int{v1, v2})
+
+	close(source.gate)
+	require.EqualValues(t, []int{3, 4}, <-ahead)
+	require.EqualValues(t, []int{3, 4}, ToSlice(readers[1]))
+	require.EqualValues(t, []int{1, 2, 3, 4}, ToSlice(readers[2]))
+}
+
+func TestTeeConcurrentPulls(t *testing.T) {
+	readers := Tee[int](NewRangeStream(0, 10_000, 1), 4)
+	results := make([][]int, len(readers))
+	var wg sync.WaitGroup
+	for i := range readers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = ToSlice(readers[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, result := range results {
+		require.EqualValues(t, ToSlice[int](NewRangeStream(0, 10_000, 1)), result)
+	}
+}
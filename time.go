@@ -0,0 +1,45 @@
+package sorted_numeric_streams
+
+import "time"
+
+// TimeStream streams sorted timestamps (e.g. of an event log). time.Time is not an ordered type,
+// so operations on timestamps are comparator variants using Time.Compare (see IntersectTimes, DiffTimes)
+type TimeStream struct {
+	times []time.Time
+	pos   int
+	asc   bool
+}
+
+func (s *TimeStream) Next() (item time.Time, ok bool) {
+	if s.pos == len(s.times) {
+		return
+	}
+	s.pos++
+	return s.times[s.pos-1], true
+}
+
+// Len returns the number of remaining timestamps
+func (s *TimeStream) Len() (int, bool) { return len(s.times) - s.pos, true }
+
+func (s *TimeStream) Asc() bool { return s.asc }
+
+// NewTimeStream returns the stream of timestamps sorted in the given direction
+func NewTimeStream(times []time.Time, asc bool) *TimeStream {
+	return &TimeStream{times: times, asc: asc}
+}
+
+// UnionTimes returns timestamps present in either stream, equal instants are emitted once (the one of stream1)
+func UnionTimes(stream1, stream2 SortedNumbersStream[time.Time], asc bool) SortedNumbersStream[time.Time] {
+	return UnionFunc(stream1, stream2, asc, time.Time.Compare, nil)
+}
+
+// IntersectTimes returns timestamps of stream1 present in stream2 too, e.g. events common to two logs
+// Timestamps are compared as instants, so the same moment in different locations matches
+func IntersectTimes(stream1, stream2 SortedNumbersStream[time.Time], asc bool) SortedNumbersStream[time.Time] {
+	return IntersectFunc(stream1, stream2, asc, time.Time.Compare)
+}
+
+// DiffTimes returns timestamps of stream1 absent from stream2
+func DiffTimes(stream1, stream2 SortedNumbersStream[time.Time], asc bool) SortedNumbersStream[time.Time] {
+	return DiffFunc(stream1, stream2, asc, time.Time.Compare)
+}
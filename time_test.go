@@ -0,0 +1,29 @@
+package sorted_numeric_streams
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeOperations(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2024, 1, 1, 10, minute, 0, 0, time.UTC) }
+	serverLog := func() *TimeStream { return NewTimeStream([]time.Time{at(1), at(2), at(5), at(7)}, true) }
+	clientLog := func() *TimeStream {
+		moscow := time.FixedZone("MSK", 3*60*60)
+		return NewTimeStream([]time.Time{at(2).In(moscow), at(3), at(7)}, true) // same instants in another zone match
+	}
+
+	require.EqualValues(t, []time.Time{at(2), at(7)}, ToSlice(IntersectTimes(serverLog(), clientLog(), true)))
+	require.EqualValues(t, []time.Time{at(1), at(5)}, ToSlice(DiffTimes(serverLog(), clientLog(), true)))
+	require.EqualValues(t, []time.Time{at(1), at(2), at(3), at(5), at(7)}, ToSlice(UnionTimes(serverLog(), clientLog(), true)))
+}
+
+func TestTimeStreamDesc(t *testing.T) {
+	at := func(hour int) time.Time { return time.Date(2024, 1, 1, hour, 0, 0, 0, time.UTC) }
+	a := NewTimeStream([]time.Time{at(5), at(3), at(1)}, false)
+	b := NewTimeStream([]time.Time{at(3), at(2)}, false)
+	require.EqualValues(t, []time.Time{at(3)}, ToSlice(IntersectTimes(a, b, false)))
+	require.Panics(t, func() { IntersectTimes(NewTimeStream(nil, true), NewTimeStream(nil, false), true) })
+}
@@ -0,0 +1,96 @@
+package sorted_numeric_streams
+
+import "golang.org/x/exp/constraints"
+
+// MergeTournament is Merge implemented with a loser (tournament) tree instead of a binary heap.
+// Replacing the winner replays a single leaf-to-root path with one comparison per level and no swaps,
+// which is cache-friendlier for hundreds of inputs. The result is exactly the one of Merge (equal items keep
+// the order of their streams), pick whichever is faster for your inputs
+func MergeTournament[T constraints.Ordered](streams []SortedNumbersStream[T], asc bool) SortedNumbersStream[T] {
+	mustMatchDirection(asc, streams...)
+	return &tournamentStream[T]{
+		streams: streams,
+		heads:   make([]T, len(streams)),
+		alive:   make([]bool, len(streams)),
+		tree:    make([]int, len(streams)),
+		asc:     asc,
+	}
+}
+
+// tournamentStream keeps the tree in an array: nodes 1..k-1 hold the stream that lost the match in the node,
+// leaf k+i is stream i and tree[0] is the stream that won the whole tournament
+type tournamentStream[T constraints.Ordered] struct {
+	streams []SortedNumbersStream[T]
+	heads   []T
+	alive   []bool // the stream has the head
+	tree    []int
+	asc     bool
+	started bool
+}
+
+func (s *tournamentStream[T]) Next() (item T, ok bool) {
+	k := len(s.streams)
+	if !s.started {
+		s.started = true
+		if k == 0 {
+			return
+		}
+		for i := range s.streams {
+			s.advance(i)
+		}
+		s.tree[0] = s.build(1)
+	}
+	if k == 0 || !s.alive[s.tree[0]] {
+		return
+	}
+
+	winner := s.tree[0]
+	item = s.heads[winner]
+	s.advance(winner)
+	for node := (winner + k) / 2; node > 0; node /= 2 { // replay the matches of the winner's leaf
+		if s.beats(s.tree[node], winner) {
+			s.tree[node], winner = winner, s.tree[node]
+		}
+	}
+	s.tree[0] = winner
+	return item, true
+}
+
+func (s *tournamentStream[T]) Asc() bool { return s.asc }
+
+// build plays the matches of the subtree and returns its winner
+func (s *tournamentStream[T]) build(node int) int {
+	if node >= len(s.streams) {
+		return node - len(s.streams)
+	}
+	left, right := s.build(2*node), s.build(2*node+1)
+	if s.beats(left, right) {
+		s.tree[node] = right
+		return left
+	}
+	s.tree[node] = left
+	return right
+}
+
+func (s *tournamentStream[T]) advance(i int) {
+	if s.streams[i] == nil {
+		s.alive[i] = false
+		return
+	}
+	s.heads[i], s.alive[i] = s.streams[i].Next()
+}
+
+// beats tells if the head of stream i goes before the head of stream j, drained streams lose every match
+func (s *tournamentStream[T]) beats(i, j int) bool {
+	if !s.alive[i] || !s.alive[j] {
+		return s.alive[i]
+	}
+	c := compareOrdered(s.heads[i], s.heads[j])
+	if c == 0 {
+		return i < j
+	}
+	if s.asc {
+		return c < 0
+	}
+	return c > 0
+}
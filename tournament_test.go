@@ -0,0 +1,87 @@
+package sorted_numeric_streams
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeTournament(t *testing.T) {
+	type test struct {
+		streams [][]int
+		asc     bool
+	}
+	tests := []test{
+		{nil, true},
+		{[][]int{{}, {}}, true},
+		{[][]int{{1, 3}}, true},
+		{[][]int{{1, 4}, {2, 5}, {3, 6}}, true},
+		{[][]int{{1, 2}, {}, {1, 2, 3}}, true},
+		{[][]int{{6, 3}, {5, 4}, {}}, false},
+		{[][]int{{1}, {1}, {0, 1}, {1, 2}, {}, {2}, {0}}, true},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("test %d", i), func(t *testing.T) {
+			require.EqualValues(t, ToSlice(Merge(mergeInputs(tt.streams), tt.asc)), ToSlice(MergeTournament(mergeInputs(tt.streams), tt.asc)))
+		})
+	}
+}
+
+func TestMergeTournamentMatchesMerge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for k := 1; k <= 40; k++ {
+		shards := randomShards(r, k, 20)
+		for _, asc := range []bool{true, false} {
+			if !asc {
+				for _, s := range shards {
+					sort.Sort(sort.Reverse(sort.IntSlice(s)))
+				}
+			}
+			require.EqualValues(t, ToSlice(Merge(mergeInputs(shards), asc)), ToSlice(MergeTournament(mergeInputs(shards), asc)), fmt.Sprintf("k=%d", k))
+		}
+	}
+}
+
+// BenchmarkMerge compares the heap and tournament tree merges of many shards
+func BenchmarkMerge(b *testing.B) {
+	shards := randomShards(rand.New(rand.NewSource(1)), 256, 1000)
+	merges := []struct {
+		name  string
+		merge func(streams []SortedNumbersStream[int], asc bool) SortedNumbersStream[int]
+	}{
+		{"heap", Merge[int]},
+		{"tournament", MergeTournament[int]},
+	}
+	for _, m := range merges {
+		b.Run(fmt.Sprintf("%s k=%d", m.name, len(shards)), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				ToSlice(m.merge(mergeInputs(shards), true))
+			}
+		})
+	}
+}
+
+func mergeInputs(shards [][]int) []SortedNumbersStream[int] {
+	streams := make([]SortedNumbersStream[int], len(shards))
+	for i, items := range shards {
+		streams[i] = NewSliceStream(items)
+	}
+	return streams
+}
+
+// randomShards makes k sorted slices of up to n items with frequent duplicates
+func randomShards(r *rand.Rand, k, n int) [][]int {
+	shards := make([][]int, k)
+	for i := range shards {
+		shards[i] = make([]int, r.Intn(n+1))
+		for j := range shards[i] {
+			shards[i][j] = r.Intn(n * 2)
+		}
+		sort.Ints(shards[i])
+	}
+	return shards
+}